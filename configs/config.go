@@ -0,0 +1,216 @@
+package configs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+)
+
+// Listen address and TLS of the server
+type ServerConfig struct {
+	Address string `json:"address"`
+	// Use X-Forwarded-For / X-Real-IP as client address (only behind a trusted reverse proxy)
+	TrustProxyHeaders bool      `json:"trustProxyHeaders"`
+	TLS               TLSConfig `json:"tls"`
+}
+
+// TLS configuration (HTTPS if the certificate is set)
+type TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// CA certificates to verify client certificates (mutual TLS)
+	ClientCAFile string `json:"clientCAFile"`
+	// Reject connections without a client certificate (verified only if given otherwise)
+	RequireClientCert bool `json:"requireClientCert"`
+}
+
+// Worker pool configuration
+type WorkerConfig struct {
+	// Maximum number of concurrent queries (server-wide / per export)
+	QueryGlobal    int `json:"queryGlobal"`
+	QueryPerExport int `json:"queryPerExport"`
+	// Maximum number of anonymization workers (server-wide / per export)
+	AnonyGlobal    int `json:"anonyGlobal"`
+	AnonyPerExport int `json:"anonyPerExport"`
+}
+
+// Compression level of exported data
+type CompressionConfig struct {
+	// gzip level (1: fastest ~ 9: best)
+	GzipLevel int `json:"gzipLevel"`
+	// zstd level (1: fastest ~ 22: best)
+	ZstdLevel int `json:"zstdLevel"`
+}
+
+// Export configuration
+type ExportConfig struct {
+	// Maximum export duration in seconds (0 is unlimited)
+	Timeout     int               `json:"timeout"`
+	Compression CompressionConfig `json:"compression"`
+}
+
+// Export manifest configuration
+type ManifestConfig struct {
+	// Ed25519 signing key (PKCS #8 PEM, created if not exists)
+	KeyFile string `json:"keyFile"`
+	// Directory of signed manifests
+	Dir string `json:"dir"`
+}
+
+// Background export job configuration
+type JobConfig struct {
+	// Number of jobs running at the same time
+	Workers int `json:"workers"`
+	// Maximum number of queued jobs
+	QueueSize int `json:"queueSize"`
+	// Directory of job states and result files
+	Dir string `json:"dir"`
+}
+
+// Artifact store configuration (finished exports kept for resumable downloads)
+type ArtifactConfig struct {
+	// Directory of artifacts
+	Dir string `json:"dir"`
+	// Retention period in hours
+	Retention int `json:"retention"`
+}
+
+// Default limits of each consumer (API key, user or client IP) per request
+type LimitConfig struct {
+	// Token bucket of export requests (0 is unlimited)
+	RequestsPerMinute float64 `json:"requestsPerMinute"`
+	Burst             int     `json:"burst"`
+	// Maximum number of simultaneous exports (0 is unlimited)
+	ConcurrentExports int `json:"concurrentExports"`
+	// Token bucket of each client address before authentication (0 is unlimited)
+	ClientRequestsPerMinute float64 `json:"clientRequestsPerMinute"`
+	ClientBurst             int     `json:"clientBurst"`
+}
+
+// OIDC authentication configuration (access tokens of staff)
+type OIDCConfig struct {
+	// Issuer of access tokens (OIDC is disabled if empty)
+	Issuer string `json:"issuer"`
+	// Required audience (not checked if empty)
+	Audience string `json:"audience"`
+	// Local JWKS file (JWKS of the issuer's discovery document is used if empty)
+	JWKSFile string `json:"jwksFile"`
+	// Claim of roles (path separated by ".", e.g. realm_access.roles)
+	RolesClaim string `json:"rolesClaim"`
+}
+
+// Authentication configuration
+type AuthConfig struct {
+	// Accept API keys of requests (authentication is required if API keys or OIDC are enabled)
+	APIKey bool `json:"apiKey"`
+	// Key store (hashed keys, created when the first key is issued)
+	KeyFile string     `json:"keyFile"`
+	OIDC    OIDCConfig `json:"oidc"`
+}
+
+// Database of request definitions and export events
+type StorageConfig struct {
+	// sqlite3 (default) or postgres
+	Driver string `json:"driver"`
+	// Data source name (file path of SQLite, connection string of PostgreSQL)
+	DSN string `json:"dsn"`
+}
+
+// Server configuration (resources/config.json)
+type Config struct {
+	Server   ServerConfig   `json:"server"`
+	Worker   WorkerConfig   `json:"worker"`
+	Export   ExportConfig   `json:"export"`
+	Manifest ManifestConfig `json:"manifest"`
+	Job      JobConfig      `json:"job"`
+	Artifact ArtifactConfig `json:"artifact"`
+	Auth     AuthConfig     `json:"auth"`
+	Limit    LimitConfig    `json:"limit"`
+	Storage  StorageConfig  `json:"storage"`
+}
+
+var (
+	config     *Config
+	configOnce sync.Once
+)
+
+/* [Function] Get server configuration (loaded once, default values for omitted fields) */
+func Get() *Config {
+	configOnce.Do(func() {
+		config = defaultConfig()
+		// 작업 경로의 설정 파일 읽기 (없으면 기본값 사용)
+		workspace, err := os.Getwd()
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		content, err := ioutil.ReadFile(path.Join(workspace, "./resources/config.json"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Print(err)
+			}
+			return
+		}
+		if err := json.Unmarshal(content, config); err != nil {
+			log.Print(err)
+			config = defaultConfig()
+		}
+	})
+	return config
+}
+
+/* [Internal function] Default configuration */
+func defaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address: ":4000",
+		},
+		Worker: WorkerConfig{
+			QueryGlobal:    16,
+			QueryPerExport: 4,
+			AnonyGlobal:    runtime.NumCPU() * 2,
+			AnonyPerExport: runtime.NumCPU(),
+		},
+		Export: ExportConfig{
+			Compression: CompressionConfig{
+				GzipLevel: 6,
+				ZstdLevel: 3,
+			},
+		},
+		Manifest: ManifestConfig{
+			KeyFile: "./resources/keys/manifest.pem",
+			Dir:     "./resources/manifests",
+		},
+		Job: JobConfig{
+			Workers:   2,
+			QueueSize: 1024,
+			Dir:       "./resources/jobs",
+		},
+		Artifact: ArtifactConfig{
+			Dir:       "./resources/artifacts",
+			Retention: 24,
+		},
+		Auth: AuthConfig{
+			APIKey:  true,
+			KeyFile: "./resources/keys/api_keys.json",
+			OIDC: OIDCConfig{
+				RolesClaim: "roles",
+			},
+		},
+		Limit: LimitConfig{
+			RequestsPerMinute:       30,
+			Burst:                   5,
+			ConcurrentExports:       2,
+			ClientRequestsPerMinute: 120,
+			ClientBurst:             20,
+		},
+		Storage: StorageConfig{
+			Driver: "sqlite3",
+			DSN:    "./resources/dems.db?_busy_timeout=5000&_journal_mode=WAL",
+		},
+	}
+}
@@ -0,0 +1,114 @@
+package allowlist
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+// Networks and client certificates allowed to access request (query.json > access, no restriction if omitted)
+type Policy struct {
+	// CIDR ranges (or single addresses) of partner networks
+	AllowedCIDRs []string `json:"allowedCIDRs"`
+	// Subjects of client certificates ("CN=partner,O=Company" or common name)
+	ClientCertSubjects []string `json:"clientCertSubjects"`
+}
+
+// Access denied by network or client certificate (403)
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	return e.Reason
+}
+
+/* [Function] Get access policy of request */
+func LoadPolicy(requestID string) (*Policy, error) {
+	policy := new(Policy)
+	stored, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		// 존재하지 않는 요청은 handler에서 처리
+		return policy, nil
+	} else if err != nil {
+		return nil, err
+	}
+	definition := struct {
+		Access *Policy `json:"access"`
+	}{Access: policy}
+	if err := json.Unmarshal(stored.Query, &definition); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+/* [Function] Check client address and verified client certificate */
+func (p *Policy) Check(clientIP string, state *tls.ConnectionState) error {
+	// 서버에서 검증된 인증서만 사용 (server.tls.clientCAFile)
+	subject, commonName := ClientSubject(state)
+	return p.CheckClient(clientIP, subject, commonName)
+}
+
+/* [Function] Check client address and subject of the verified client certificate (empty subject if not verified) */
+func (p *Policy) CheckClient(clientIP string, subject string, commonName string) error {
+	if len(p.AllowedCIDRs) > 0 {
+		allowed, err := p.allowedIP(net.ParseIP(clientIP))
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return &DeniedError{Reason: "Client address is not allowed: " + clientIP}
+		}
+	}
+	if len(p.ClientCertSubjects) > 0 {
+		if subject == "" {
+			return &DeniedError{Reason: "Verified client certificate is required"}
+		}
+		for _, allowed := range p.ClientCertSubjects {
+			if allowed == subject || allowed == commonName {
+				return nil
+			}
+		}
+		return &DeniedError{Reason: "Client certificate is not allowed: " + subject}
+	}
+	return nil
+}
+
+/* [Function] Subject and common name of the verified client certificate (empty if not verified) */
+func ClientSubject(state *tls.ConnectionState) (string, string) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", ""
+	}
+	subject := state.VerifiedChains[0][0].Subject
+	return subject.String(), subject.CommonName
+}
+
+/* [Internal function] Whether the address is in the allowed ranges */
+func (p *Policy) allowedIP(ip net.IP) (bool, error) {
+	if ip == nil {
+		return false, nil
+	}
+	for _, cidr := range p.AllowedCIDRs {
+		// 단일 주소는 /32 (IPv6는 /128)
+		if !strings.Contains(cidr, "/") {
+			if single := net.ParseIP(cidr); single != nil {
+				if single.Equal(ip) {
+					return true, nil
+				}
+				continue
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return false, errors.New("Invalid CIDR in access policy: " + cidr)
+		}
+		if network.Contains(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,131 @@
+package allowlist
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+func TestMain(m *testing.M) {
+	// 요청 정의는 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-allowlist")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestCheckAddress(t *testing.T) {
+	policy := &Policy{AllowedCIDRs: []string{"10.20.0.0/16", "192.168.1.7", "2001:db8::/32", "::1"}}
+	cases := map[string]bool{
+		"10.20.0.1":        true,
+		"10.20.255.254":    true,
+		"10.21.0.1":        false,
+		"192.168.1.7":      true,
+		"192.168.1.8":      false,
+		"2001:db8::1":      true,
+		"2001:db9::1":      false,
+		"::1":              true,
+		"::ffff:10.20.0.1": true,
+		"":                 false,
+		"not-an-address":   false,
+	}
+	for ip, expected := range cases {
+		err := policy.Check(ip, nil)
+		if expected && err != nil {
+			t.Errorf("%s: %v", ip, err)
+		} else if !expected {
+			if _, ok := err.(*DeniedError); !ok {
+				t.Errorf("%s: error = %v, want denied", ip, err)
+			}
+		}
+	}
+	// 잘못된 CIDR은 거부 사유가 아닌 설정 오류
+	invalid := &Policy{AllowedCIDRs: []string{"10.0.0.0/33"}}
+	if err := invalid.Check("10.0.0.1", nil); err == nil {
+		t.Error("invalid CIDR is accepted")
+	} else if _, denied := err.(*DeniedError); denied {
+		t.Errorf("invalid CIDR is denied: %v", err)
+	}
+	// 제한 없음
+	if err := (&Policy{}).Check("203.0.113.1", nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	state := func(subject pkix.Name) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: subject}
+		return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	partner := pkix.Name{CommonName: "partner", Organization: []string{"Company"}}
+	other := pkix.Name{CommonName: "other", Organization: []string{"Company"}}
+
+	// 전체 DN 또는 CN으로 지정
+	byDN := &Policy{ClientCertSubjects: []string{"CN=partner,O=Company"}}
+	byCN := &Policy{ClientCertSubjects: []string{"partner"}}
+	for name, policy := range map[string]*Policy{"DN": byDN, "CN": byCN} {
+		if err := policy.Check("127.0.0.1", state(partner)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if err := policy.Check("127.0.0.1", state(other)); !isDenied(err) {
+			t.Errorf("%s: other certificate: %v", name, err)
+		}
+		// 검증되지 않은 인증서와 인증서 없는 연결은 거부
+		unverified := state(partner)
+		unverified.VerifiedChains = nil
+		for _, connection := range []*tls.ConnectionState{nil, {}, unverified} {
+			if err := policy.Check("127.0.0.1", connection); !isDenied(err) {
+				t.Errorf("%s: connection without verified certificate: %v", name, err)
+			}
+		}
+	}
+	// 네트워크와 인증서 모두 확인
+	both := &Policy{AllowedCIDRs: []string{"10.0.0.0/8"}, ClientCertSubjects: []string{"partner"}}
+	if err := both.Check("10.1.2.3", state(partner)); err != nil {
+		t.Error(err)
+	}
+	if err := both.Check("172.16.0.1", state(partner)); !isDenied(err) {
+		t.Errorf("other network with allowed certificate: %v", err)
+	}
+	if err := both.Check("10.1.2.3", state(other)); !isDenied(err) {
+		t.Errorf("allowed network with other certificate: %v", err)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	query := `{ "access": { "allowedCIDRs": ["10.0.0.0/8"], "clientCertSubjects": ["partner"] } }`
+	if err := storage.SaveDefinition("allowlist-load", json.RawMessage(query), nil); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicy("allowlist-load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.AllowedCIDRs) != 1 || len(policy.ClientCertSubjects) != 1 {
+		t.Fatalf("policy = %+v", policy)
+	}
+	// 존재하지 않는 요청은 제한 없음 (handler에서 404)
+	policy, err = LoadPolicy("allowlist-missing")
+	if err != nil || len(policy.AllowedCIDRs) != 0 || len(policy.ClientCertSubjects) != 0 {
+		t.Fatalf("policy of missing request = %+v (%v)", policy, err)
+	}
+}
+
+/* [Internal function] Check if access is denied by the policy */
+func isDenied(err error) bool {
+	_, ok := err.(*DeniedError)
+	return ok
+}
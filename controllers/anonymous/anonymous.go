@@ -2,21 +2,25 @@ package anonymous
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math"
 	"net/http"
-	"os"
-	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	// Custom package
+	"dems-api-server/controllers/export"
+	"dems-api-server/controllers/query"
+	"dems-api-server/controllers/storage"
 )
 
 // define some error code
@@ -48,6 +52,18 @@ type AnoOption struct {
 	Linear     string `json:"linear,omitempty"`
 }
 
+// anonyFunc anonymizes typed value of column (value is not nil)
+type anonyFunc func(value interface{}) interface{}
+
+// SaveResult defines the result of streaming data to the response
+type SaveResult struct {
+	Rows uint64
+	Err  error
+}
+
+// Context key of the saved row counter (progress of background export)
+type progressKey struct{}
+
 // Option defines the field anonymization method parameter format
 type Option struct {
 	Method      string    `json:"method"`
@@ -103,7 +119,7 @@ func buildEncryptingFunc(options AnoOption) func(string) string {
 	}
 }
 
-func buildRoundingFunc(options AnoOption) func(string) string {
+func buildRoundingFunc(options AnoOption) anonyFunc {
 	/*position, err := strconv.ParseInt(options.Position, 10, 0)
 	if err != nil {
 		return func (inString string) string {
@@ -114,61 +130,43 @@ func buildRoundingFunc(options AnoOption) func(string) string {
 	posPower := math.Pow(10, math.Abs(float64(position)))
 	switch options.Algorithm {
 	case "round":
-		return func(inString string) string {
-			if value, err := strconv.ParseFloat(inString, 64); err == nil {
-				if position > 0 {
-					return strconv.FormatFloat(math.Round(value*posPower)/posPower, 'f', position, 64)
-				}
-				return strconv.FormatFloat(math.Round(value/posPower)*posPower, 'f', 0, 64)
+		return numericFunc(func(value float64) string {
+			if position > 0 {
+				return strconv.FormatFloat(math.Round(value*posPower)/posPower, 'f', position, 64)
 			}
-			return "parseFloat error:" + inString
-		}
+			return strconv.FormatFloat(math.Round(value/posPower)*posPower, 'f', 0, 64)
+		})
 	case "ceil":
-		return func(inString string) string {
-			if value, err := strconv.ParseFloat(inString, 64); err == nil {
-				if position > 0 {
-					return strconv.FormatFloat(math.Ceil(value*posPower)/posPower, 'f', position, 64)
-				}
-				return strconv.FormatFloat(math.Ceil(value/posPower)*posPower, 'f', 0, 64)
+		return numericFunc(func(value float64) string {
+			if position > 0 {
+				return strconv.FormatFloat(math.Ceil(value*posPower)/posPower, 'f', position, 64)
 			}
-			return "parseFloat error:" + inString
-
-		}
+			return strconv.FormatFloat(math.Ceil(value/posPower)*posPower, 'f', 0, 64)
+		})
 	case "floor":
-		return func(inString string) string {
-			if value, err := strconv.ParseFloat(inString, 64); err == nil {
-				if position > 0 {
-					return strconv.FormatFloat(math.Floor(value*posPower)/posPower, 'f', position, 64)
-				}
-				return strconv.FormatFloat(math.Floor(value/posPower)*posPower, 'f', 0, 64)
+		return numericFunc(func(value float64) string {
+			if position > 0 {
+				return strconv.FormatFloat(math.Floor(value*posPower)/posPower, 'f', position, 64)
 			}
-			return "parseFloat error:" + inString
-		}
+			return strconv.FormatFloat(math.Floor(value/posPower)*posPower, 'f', 0, 64)
+		})
 	default:
-		return func(inString string) string {
-			return "unknown Rounding algorithm"
-		}
+		return constFunc("unknown Rounding algorithm")
 	}
 }
 
-func buildRangingFunc(options AnoOption) func(string) string {
+func buildRangingFunc(options AnoOption) anonyFunc {
 	lowBound, err := strconv.ParseFloat(options.Lower, 64)
 	if err != nil {
-		return func(inString string) string {
-			return "lower parameter error"
-		}
+		return constFunc("lower parameter error")
 	}
 	upBound, err2 := strconv.ParseFloat(options.Upper, 64)
 	if err2 != nil {
-		return func(inString string) string {
-			return "upper parameter error"
-		}
+		return constFunc("upper parameter error")
 	}
 	binNumP, err3 := strconv.ParseInt(options.Bin, 10, 0)
 	if err3 != nil {
-		return func(inString string) string {
-			return "bin parameter error"
-		}
+		return constFunc("bin parameter error")
 	}
 	binNum := int(binNumP)
 	boundary := []float64{}
@@ -178,20 +176,55 @@ func buildRangingFunc(options AnoOption) func(string) string {
 	}
 	boundary = append(boundary, upBound)
 
-	return func(inString string) string {
-		if value, err := strconv.ParseFloat(inString, 64); err == nil {
-			before := ""
-			last := ""
-			for _, bound := range boundary {
-				if bound > value {
-					return fmt.Sprint(before, " ~ ", bound)
-				}
-				before = fmt.Sprintf("%v", bound) //bound
-				last = fmt.Sprintf("%v", bound)
+	return numericFunc(func(value float64) string {
+		before := ""
+		last := ""
+		for _, bound := range boundary {
+			if bound > value {
+				return fmt.Sprint(before, " ~ ", bound)
 			}
-			return fmt.Sprint(last, " ~ ")
+			before = fmt.Sprintf("%v", bound) //bound
+			last = fmt.Sprintf("%v", bound)
 		}
-		return "parseFloat error:" + inString
+		return fmt.Sprint(last, " ~ ")
+	})
+}
+
+/* [Internal function] Anonymization function using text of value (hash, masking) */
+func textFunc(f func(string) string) anonyFunc {
+	return func(value interface{}) interface{} {
+		return f(query.ValueString(value))
+	}
+}
+
+/* [Internal function] Anonymization function using numeric value (integer, float, decimal or numeric text) */
+func numericFunc(f func(float64) string) anonyFunc {
+	return func(value interface{}) interface{} {
+		var number float64
+		var err error
+		switch v := value.(type) {
+		case int64:
+			number = float64(v)
+		case float64:
+			number = v
+		case query.Decimal:
+			number, err = strconv.ParseFloat(string(v), 64)
+		case string:
+			number, err = strconv.ParseFloat(v, 64)
+		default:
+			err = errors.New("not a number")
+		}
+		if err != nil {
+			return "parseFloat error:" + query.ValueString(value)
+		}
+		return f(number)
+	}
+}
+
+/* [Internal function] Anonymization function returning fixed value (invalid option) */
+func constFunc(result string) anonyFunc {
+	return func(value interface{}) interface{} {
+		return result
 	}
 }
 
@@ -238,15 +271,13 @@ func buildMaskingFunc(options AnoOption) func(string) string {
 	}
 }
 
-func procData(options map[string]Option, headerInfo []string, iChan <-chan []string, oChan chan<- []string, termChan chan<- bool) {
+func procData(ctx context.Context, options map[string]Option, headerInfo []string, iChan <-chan query.Row, oChan chan<- query.Row, termChan chan<- error) {
 	// build processing functions
-	funcList := [](func(string) string){}
-	passAsIs := func(inString string) string {
-		return inString
-	}
-	dropAll := func(inString string) string {
-		return ""
+	funcList := []anonyFunc{}
+	passAsIs := func(value interface{}) interface{} {
+		return value
 	}
+	dropAll := constFunc("")
 	//fmt.Println("Within procData...")
 	//fmt.Println(options)
 	for _, key := range headerInfo {
@@ -254,7 +285,7 @@ func procData(options map[string]Option, headerInfo []string, iChan <-chan []str
 			switch option.Method {
 			case "encryption":
 				//fmt.Println(key, ":Encrypting")
-				funcList = append(funcList, buildEncryptingFunc(option.Options))
+				funcList = append(funcList, textFunc(buildEncryptingFunc(option.Options)))
 			case "rounding":
 				//fmt.Println(key, ":Rounding")
 				funcList = append(funcList, buildRoundingFunc(option.Options))
@@ -263,10 +294,10 @@ func procData(options map[string]Option, headerInfo []string, iChan <-chan []str
 				funcList = append(funcList, buildRangingFunc(option.Options))
 			case "blank_impute":
 				//fmt.Println(key, ":Masking")
-				funcList = append(funcList, buildMaskingFunc(option.Options))
+				funcList = append(funcList, textFunc(buildMaskingFunc(option.Options)))
 			case "pii_reduction":
 				//fmt.Println(key, ":Masking pii")
-				funcList = append(funcList, buildMaskingFunc(option.Options))
+				funcList = append(funcList, textFunc(buildMaskingFunc(option.Options)))
 			case "non":
 				//fmt.Println(key, ":non")
 				funcList = append(funcList, passAsIs)
@@ -284,95 +315,207 @@ func procData(options map[string]Option, headerInfo []string, iChan <-chan []str
 	// fmt.Println("Proc running...")
 
 	cnt := 0
-	for v, ok := <-iChan; ok; v, ok = <-iChan {
-		// blocking
+	for {
+		// blocking (stop when export is aborted)
+		var v query.Row
+		var ok bool
+		select {
+		case v, ok = <-iChan:
+		case <-ctx.Done():
+			printLog("debug", "Routine(Anonymization) aborted (DataCount:" + strconv.Itoa(cnt) + ")")
+			termChan <- ctx.Err()
+			return
+		}
+		if !ok {
+			break
+		}
 		// do some processing
 		// and then send the result to oChan
-		output := []string{}
+		// NULL은 비식별화 방법과 관계없이 NULL로 유지
+		output := make(query.Row, len(v))
 		for i, value := range v {
-			output = append(output, funcList[i](value))
+			if value != nil {
+				output[i] = funcList[i](value)
+			}
 		}
 		//fmt.Print(output)
-		oChan <- output
+		select {
+		case oChan <- output:
+		case <-ctx.Done():
+			printLog("debug", "Routine(Anonymization) aborted (DataCount:" + strconv.Itoa(cnt) + ")")
+			termChan <- ctx.Err()
+			return
+		}
 		cnt++
 	}
 
 	printLog("debug", "Routine(Anonymization) exit (DataCount:" + strconv.Itoa(cnt) + ")")
-	termChan <- true
+	termChan <- nil
 }
 
 /* [Function] 비식별화 처리 */
-func Anonymization(requestID string, nProc uint64, header []string, rawDataQueue <-chan []string, pcdDataQueue chan<- []string, nProcAnony chan<- bool) {
-	// 비식별화 정보를 가진 파일 경로 생성
-	workspace, err := os.Getwd()
+func Anonymization(ctx context.Context, requestID string, nWorker uint64, header []string, rawDataQueue <-chan query.Row, pcdDataQueue chan<- query.Row, nProcAnony chan<- error) error {
+	options, err := loadOptions(requestID)
 	if err != nil {
-		printLog("error", err.Error())
-		return
+		return err
 	}
-	filePath := path.Join(workspace, "./resources/processed/", requestID, "options.json")
+
+	// 비식별화 처리
+	for i := uint64(0); i < nWorker; i++ {
+		go procData(ctx, options, header, rawDataQueue, pcdDataQueue, nProcAnony)
+	}
+	return nil
+}
+
+/* [Function] 비식별화로 값이 변환되는 컬럼 확인 (원본 타입을 유지할 수 없으므로 문자열로 반출) */
+func TransformedColumns(requestID string, header []string) ([]bool, error) {
+	options, err := ColumnOptions(requestID, header)
+	if err != nil {
+		return nil, err
+	}
+	transformed := make([]bool, len(header))
+	for i, option := range options {
+		transformed[i] = option.Method != "non"
+	}
+	return transformed, nil
+}
+
+/* [Function] 컬럼별 비식별화 옵션 (옵션이 없는 컬럼은 그대로 반출되므로 "non") */
+func ColumnOptions(requestID string, header []string) ([]Option, error) {
+	options, err := loadOptions(requestID)
+	if err != nil {
+		return nil, err
+	}
+	columnOptions := make([]Option, len(header))
+	for i, key := range header {
+		if option, exists := options[key]; exists {
+			columnOptions[i] = option
+		} else {
+			columnOptions[i] = Option{Method: "non"}
+		}
+	}
+	return columnOptions, nil
+}
+
+/* [Function] SHA-256 of the anonymization options (stored as given, recorded in the export manifest) */
+func OptionsDigest(requestID string) (string, error) {
+	optionContent, err := readOptions(requestID)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(optionContent)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+/* [Internal function] 비식별화 옵션 파일 읽기 */
+func loadOptions(requestID string) (map[string]Option, error) {
 	// 옵션 파일 데이터 읽어오기
-	optionContent, err := ioutil.ReadFile(filePath)
+	optionContent, err := readOptions(requestID)
 	if err != nil {
-		fmt.Print(err)
+		return nil, err
 	}
 	// 데이터 변환(buffer -> json)
 	var options map[string]Option
 	err = json.Unmarshal(optionContent, &options)
 	if err != nil {
-		fmt.Print(err)
+		printLog("error", err.Error())
+		return nil, err
 	}
+	return options, nil
+}
 
-	// 비식별화 처리
-	for i := uint64(0); i < nProc; i++ {
-		go procData(options, header, rawDataQueue, pcdDataQueue, nProcAnony)
+/* [Internal function] Read anonymization options of request (request definition in the database) */
+func readOptions(requestID string) ([]byte, error) {
+	definition, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		err = errors.New("Request not found: " + requestID)
+	}
+	if err != nil {
+		printLog("error", err.Error())
+		return nil, err
+	}
+	if definition.Options == nil {
+		err := errors.New("Anonymization options are not defined: " + requestID)
+		printLog("error", err.Error())
+		return nil, err
 	}
+	return definition.Options, nil
+}
+
+/* [Function] Context that reports the number of saved rows to counter (updated atomically) */
+func WithProgress(ctx context.Context, counter *uint64) context.Context {
+	return context.WithValue(ctx, progressKey{}, counter)
 }
 
-/* [Function] 비식별화된 데이터 저장 */
-func SaveData(res http.ResponseWriter, header []string, pcdDataQueue <-chan []string, quitProc chan<- bool) {
+/* [Function] 비식별화된 데이터 저장 (writer: 반출 파일 형식, stream: 압축) */
+func SaveData(ctx context.Context, res http.ResponseWriter, writer export.Writer, stream *export.Compressor, encryptor *export.Encryptor, header []string, pcdDataQueue <-chan query.Row, quitProc chan<- SaveResult) {
 	// response header 설정
 	res.Header().Set("Connection", "Keep-Alive")
 	res.Header().Set("Transfer-Encoding", "chunked")
 	res.Header().Set("X-Content-Type-Options", "nosniff")
+	// 반출 결과(데이터 수, 성공 여부)는 스트림 종료 후 trailer로 전달
+	res.Header().Set("Trailer", "X-Export-Rows, X-Export-Status, X-Export-SHA256")
+	// Accept-Encoding에 따라 압축 여부가 달라짐
+	res.Header().Set("Vary", "Accept-Encoding")
+	if encoding := stream.ContentEncodingHeader(); encoding != "" {
+		res.Header().Set("Content-Encoding", encoding)
+	}
 	// stream file setting
-	res.Header().Set("Content-Disposition", "attachment;filename=exportData.csv")
-	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Disposition", "attachment;filename=" + export.FileName(writer, stream, encryptor))
+	res.Header().Set("Content-Type", encryptor.ContentType(stream.ContentType(writer.ContentType())))
+	res.WriteHeader(http.StatusOK)
 	// Write
-	count := 0
-	numFields := len(header)
-	var buf bytes.Buffer
-	for i, v := range(header) {
-		if strings.ContainsAny(v, ", ") {
-			buf.Write([]byte("\"" + v + "\""))
-		} else {
-			buf.Write([]byte(v))
+	count := uint64(0)
+	progress, _ := ctx.Value(progressKey{}).(*uint64)
+	if err := writer.WriteHeader(header); err != nil {
+		printLog("error", err.Error())
+		quitProc <- SaveResult{Rows: count, Err: err}
+		return
+	}
+	SaveLoop:
+	for {
+		var x query.Row
+		var ok bool
+		select {
+		case x, ok = <-pcdDataQueue:
+		case <-ctx.Done():
+			printLog("debug", "Routine(Save) aborted (DataCount:" + strconv.FormatUint(count, 10) + ")")
+			quitProc <- SaveResult{Rows: count, Err: ctx.Err()}
+			return
 		}
-		if i < numFields -1 {
-			buf.Write([]byte(", "))
+		if !ok {
+			break SaveLoop
 		}
-	}
-	buf.Write([]byte("\r\n"))
-	res.Write(buf.Bytes())
-	buf.Reset()
-	/* SaveLoop: */
-	for x, ok := <-pcdDataQueue; ok; x, ok = <-pcdDataQueue {
-		for i, v := range(x) {
-			if strings.ContainsAny(v, ", ") {
-				buf.Write([]byte("\"" + v + "\""))
-			} else {
-				buf.Write([]byte(v))
-			}
-			if i < numFields -1 {
-				buf.Write([]byte(", "))
-			}
+		// 클라이언트 연결이 끊어진 경우 저장 중단
+		if err := writer.WriteRow(x); err != nil {
+			printLog("error", err.Error())
+			printLog("debug", "Routine(Save) aborted (DataCount:" + strconv.FormatUint(count, 10) + ")")
+			quitProc <- SaveResult{Rows: count, Err: err}
+			return
 		}
-		buf.Write([]byte("\r\n"))
-		res.Write(buf.Bytes())
-		buf.Reset()
 		count++
+		if progress != nil {
+			atomic.StoreUint64(progress, count)
+		}
+	}
+	// 버퍼에 남은 데이터 전송 (압축 및 암호화 스트림 종료)
+	if err := writer.Close(); err != nil {
+		printLog("error", err.Error())
+		quitProc <- SaveResult{Rows: count, Err: err}
+		return
+	}
+	if err := stream.Close(); err != nil {
+		printLog("error", err.Error())
+		quitProc <- SaveResult{Rows: count, Err: err}
+		return
+	}
+	if err := encryptor.Close(); err != nil {
+		printLog("error", err.Error())
+		quitProc <- SaveResult{Rows: count, Err: err}
+		return
 	}
-	fmt.Println("SaveLoop writes total", count, "lines")
-	quitProc <- true
+	printLog("debug", "Routine(Save) exit (DataCount:" + strconv.FormatUint(count, 10) + ")")
+	quitProc <- SaveResult{Rows: count}
 }
 
 /* [Internal function] Print log */
@@ -0,0 +1,311 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+const (
+	// Key format: dems_<key ID>_<secret>
+	KEY_PREFIX = "dems_"
+	// Request header of API key
+	HEADER = "X-API-Key"
+	// Echo context key of authenticated key ID
+	CONTEXT_KEY = "apiKey"
+)
+
+// Key ID format (16 hex characters)
+var keyIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+var (
+	ErrInvalidKey = errors.New("Invalid API key")
+	ErrNotFound   = errors.New("API key not found")
+)
+
+// API key of export request (only SHA-256 of the secret is stored)
+type Key struct {
+	ID        string     `json:"id"`
+	RequestID string     `json:"requestId"`
+	Name      string     `json:"name,omitempty"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Key store file (cached until the file is modified)
+type store struct {
+	Keys []*Key `json:"keys"`
+}
+
+var (
+	cache        *store
+	cacheModTime time.Time
+	storeMu      sync.Mutex
+)
+
+/* [Function] Validate API key and return its entry (revoked or expired key is invalid) */
+func Validate(token string, now time.Time) (*Key, error) {
+	keyID, secret, ok := parseToken(token)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	keys, err := load()
+	if err != nil {
+		return nil, err
+	}
+	key := keys.find(keyID)
+	if key == nil || !key.Active(now) {
+		return nil, ErrInvalidKey
+	}
+	digest := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidKey
+	}
+	copied := *key
+	return &copied, nil
+}
+
+/* [Function] Get key entry by key ID (ErrNotFound if not exists) */
+func Get(keyID string) (*Key, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	keys, err := load()
+	if err != nil {
+		return nil, err
+	}
+	key := keys.find(keyID)
+	if key == nil {
+		return nil, ErrNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+/* [Function] Whether the key is not revoked and not expired */
+func (k *Key) Active(now time.Time) bool {
+	if k.RevokedAt != nil && !now.Before(*k.RevokedAt) {
+		return false
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+/* [Function] Issue API key for request (the key is shown only once) */
+func Issue(requestID string, name string, expiresAt *time.Time) (*Key, string, error) {
+	if requestID == "" {
+		return nil, "", errors.New("Request ID is required")
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	keys, err := load()
+	if err != nil {
+		return nil, "", err
+	}
+	key, token, err := newKey(requestID, name, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	keys.Keys = append(keys.Keys, key)
+	if err := keys.save(); err != nil {
+		return nil, "", err
+	}
+	return key, token, nil
+}
+
+/* [Function] Issue new key of the same request and expire the old key after grace period */
+func Rotate(keyID string, grace time.Duration) (*Key, string, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	keys, err := load()
+	if err != nil {
+		return nil, "", err
+	}
+	old := keys.find(keyID)
+	now := time.Now().UTC()
+	if old == nil || !old.Active(now) {
+		return nil, "", ErrNotFound
+	}
+	key, token, err := newKey(old.RequestID, old.Name, old.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	// 기존 키는 유예 기간 동안 사용 가능
+	expiresAt := now.Add(grace)
+	if old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &expiresAt
+	}
+	keys.Keys = append(keys.Keys, key)
+	if err := keys.save(); err != nil {
+		return nil, "", err
+	}
+	return key, token, nil
+}
+
+/* [Function] Revoke key immediately */
+func Revoke(keyID string) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	keys, err := load()
+	if err != nil {
+		return err
+	}
+	key := keys.find(keyID)
+	if key == nil {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+	}
+	return keys.save()
+}
+
+/* [Function] List keys (of request if requestID is not empty) */
+func List(requestID string) ([]Key, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	keys, err := load()
+	if err != nil {
+		return nil, err
+	}
+	var list []Key
+	for _, key := range keys.Keys {
+		if requestID == "" || key.RequestID == requestID {
+			list = append(list, *key)
+		}
+	}
+	return list, nil
+}
+
+/* [Internal function] Create key entry and token */
+func newKey(requestID string, name string, expiresAt *time.Time) (*Key, string, error) {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := &Key{
+		ID:        hex.EncodeToString(id),
+		RequestID: requestID,
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	encoded := hex.EncodeToString(secret)
+	key.Hash = hashSecret(encoded)
+	return key, KEY_PREFIX + key.ID + "_" + encoded, nil
+}
+
+/* [Internal function] Split token into key ID and secret */
+func parseToken(token string) (string, string, bool) {
+	if !strings.HasPrefix(token, KEY_PREFIX) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(token, KEY_PREFIX), "_", 2)
+	if len(parts) != 2 || !keyIDPattern.MatchString(parts[0]) || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func hashSecret(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(digest[:])
+}
+
+func (s *store) find(keyID string) *Key {
+	for _, key := range s.Keys {
+		if key.ID == keyID {
+			return key
+		}
+	}
+	return nil
+}
+
+/* [Internal function] Load key store (keys may be changed by the key command while the server is running) */
+func load() (*store, error) {
+	file, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		cache = &store{}
+		cacheModTime = time.Time{}
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if cache != nil && info.ModTime().Equal(cacheModTime) {
+		return cache, nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	loaded := new(store)
+	if err := json.Unmarshal(content, loaded); err != nil {
+		return nil, err
+	}
+	cache = loaded
+	cacheModTime = info.ModTime()
+	return cache, nil
+}
+
+/* [Internal function] Save key store (written to temporary file, then renamed) */
+func (s *store) save() error {
+	file, err := storePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file+".tmp", content, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(file+".tmp", file); err != nil {
+		return err
+	}
+	// 다음 조회 시 파일에서 다시 읽음
+	cache = nil
+	return nil
+}
+
+/* [Internal function] Key store path (relative to the workspace) */
+func storePath() (string, error) {
+	file := configs.Get().Auth.KeyFile
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	workspace, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(workspace, file), nil
+}
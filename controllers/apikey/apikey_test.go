@@ -0,0 +1,151 @@
+package apikey
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 키 저장 파일은 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-apikey")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestIssueAndValidate(t *testing.T) {
+	key, token, err := Issue("apikey-issue", "partner", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, KEY_PREFIX+key.ID+"_") {
+		t.Fatalf("token = %s", token)
+	}
+	// 비밀 값은 저장하지 않음
+	content, err := ioutil.ReadFile(mustStorePath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := token[strings.LastIndex(token, "_")+1:]
+	if strings.Contains(string(content), secret) || !strings.Contains(string(content), key.Hash) {
+		t.Fatal("secret is stored in the key file")
+	}
+
+	validated, err := Validate(token, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validated.ID != key.ID || validated.RequestID != "apikey-issue" || validated.Name != "partner" {
+		t.Fatalf("validated key = %+v", validated)
+	}
+	for _, invalid := range []string{
+		"",
+		secret,
+		KEY_PREFIX + key.ID,
+		KEY_PREFIX + key.ID + "_",
+		KEY_PREFIX + key.ID + "_" + secret + "0",
+		KEY_PREFIX + strings.ToUpper(key.ID) + "_" + secret,
+		KEY_PREFIX + "0000000000000000_" + secret,
+		"x" + token,
+	} {
+		if _, err := Validate(invalid, time.Now()); err != ErrInvalidKey {
+			t.Errorf("token %q: %v", invalid, err)
+		}
+	}
+	if _, _, err := Issue("", "", nil); err == nil {
+		t.Error("key without request is issued")
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	_, token, err := Issue("apikey-expire", "", &expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Validate(token, expiresAt.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Validate(token, expiresAt); err != ErrInvalidKey {
+		t.Fatalf("expired key: %v", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	old, oldToken, err := Issue("apikey-rotate", "batch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grace := time.Hour
+	rotated := time.Now()
+	key, token, err := Rotate(old.ID, grace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID == old.ID || key.RequestID != old.RequestID || key.Name != old.Name || token == oldToken {
+		t.Fatalf("rotated key = %+v", key)
+	}
+	// 기존 키는 유예 기간 동안만 사용 가능
+	if _, err := Validate(oldToken, rotated.Add(grace-time.Minute)); err != nil {
+		t.Fatalf("old key within grace period: %v", err)
+	}
+	if _, err := Validate(oldToken, rotated.Add(grace+time.Minute)); err != ErrInvalidKey {
+		t.Fatalf("old key after grace period: %v", err)
+	}
+	if _, err := Validate(token, rotated.Add(grace+time.Minute)); err != nil {
+		t.Fatalf("new key after grace period: %v", err)
+	}
+	// 새 키의 만료 시간은 기존 키의 원래 만료 시간을 유지
+	if key.ExpiresAt != nil {
+		t.Fatalf("new key expires at %v", key.ExpiresAt)
+	}
+	if _, _, err := Rotate("0000000000000000", grace); err != ErrNotFound {
+		t.Fatalf("rotate missing key: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	key, token, err := Issue("apikey-revoke", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Revoke(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Validate(token, time.Now()); err != ErrInvalidKey {
+		t.Fatalf("revoked key: %v", err)
+	}
+	// 폐기된 키는 교체할 수 없음
+	if _, _, err := Rotate(key.ID, time.Hour); err != ErrNotFound {
+		t.Fatalf("rotate revoked key: %v", err)
+	}
+	if err := Revoke("0000000000000000"); err != ErrNotFound {
+		t.Fatalf("revoke missing key: %v", err)
+	}
+	list, err := List("apikey-revoke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].RevokedAt == nil {
+		t.Fatalf("keys = %+v", list)
+	}
+}
+
+/* [Internal function] Path of key store file */
+func mustStorePath(t *testing.T) string {
+	file, err := storePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
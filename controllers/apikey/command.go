@@ -0,0 +1,124 @@
+package apikey
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+const usageText = `Usage:
+  key issue [-name NAME] [-ttl DURATION] <requestID>
+  key rotate [-grace DURATION] <keyID>
+  key revoke <keyID>
+  key list [requestID]`
+
+/* [Function] Manage API keys (key issue / rotate / revoke / list) */
+func Command(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usageText)
+	}
+	flags := flag.NewFlagSet("key "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+	switch args[0] {
+	case "issue":
+		name := flags.String("name", "", "name of the requesting party")
+		ttl := flags.Duration("ttl", 0, "validity of the key (e.g. 720h, 0 is unlimited)")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New(usageText)
+		}
+		requestID := flags.Arg(0)
+		if err := checkRequest(requestID); err != nil {
+			return err
+		}
+		var expiresAt *time.Time
+		if *ttl > 0 {
+			expires := time.Now().UTC().Add(*ttl)
+			expiresAt = &expires
+		}
+		key, token, err := Issue(requestID, *name, expiresAt)
+		if err != nil {
+			return err
+		}
+		printIssued(out, key, token)
+	case "rotate":
+		grace := flags.Duration("grace", time.Hour, "period the old key remains valid")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New(usageText)
+		}
+		key, token, err := Rotate(flags.Arg(0), *grace)
+		if err != nil {
+			return err
+		}
+		printIssued(out, key, token)
+	case "revoke":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New(usageText)
+		}
+		if err := Revoke(flags.Arg(0)); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Revoked "+flags.Arg(0))
+	case "list":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		keys, err := List(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tREQUEST\tNAME\tCREATED\tEXPIRES\tSTATE")
+		for _, key := range keys {
+			state := "active"
+			if key.RevokedAt != nil {
+				state = "revoked"
+			} else if !key.Active(now) {
+				state = "expired"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.RequestID, key.Name, key.CreatedAt.Format(time.RFC3339), formatTime(key.ExpiresAt), state)
+		}
+		table.Flush()
+	default:
+		return errors.New(usageText)
+	}
+	return nil
+}
+
+/* [Internal function] Outputs issued key (the secret is not stored, so it is shown only here) */
+func printIssued(out io.Writer, key *Key, token string) {
+	fmt.Fprintln(out, "Request: "+key.RequestID)
+	fmt.Fprintln(out, "Key ID:  "+key.ID)
+	fmt.Fprintln(out, "Expires: "+formatTime(key.ExpiresAt))
+	fmt.Fprintln(out, "API key: "+token)
+}
+
+/* [Internal function] Check request definition exists */
+func checkRequest(requestID string) error {
+	_, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		return errors.New("Request not found: " + requestID)
+	}
+	return err
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
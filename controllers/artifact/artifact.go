@@ -0,0 +1,208 @@
+package artifact
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/manifest"
+)
+
+// Interval of removing expired artifacts
+const cleanInterval = 10 * time.Minute
+
+// Expired (or removed) artifact
+var ErrExpired = errors.New("Export has expired")
+
+// Finished export (<exportID>.json, the file is <exportID>.data)
+type Artifact struct {
+	ExportID        string    `json:"exportId"`
+	RequestID       string    `json:"requestId"`
+	FileName        string    `json:"fileName"`
+	ContentType     string    `json:"contentType"`
+	ContentEncoding string    `json:"contentEncoding,omitempty"`
+	Size            uint64    `json:"size"`
+	SHA256          string    `json:"sha256"`
+	CreatedAt       time.Time `json:"createdAt"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// Writer of artifact file (copy of the response body, write error does not stop the response)
+type Writer struct {
+	exportID string
+	file     *os.File
+	err      error
+	done     bool
+}
+
+var cleanOnce sync.Once
+
+/* [Function] Start removing expired artifacts periodically */
+func Start() {
+	cleanOnce.Do(func() {
+		go func() {
+			for {
+				clean()
+				time.Sleep(cleanInterval)
+			}
+		}()
+	})
+}
+
+/* [Function] Create artifact writer of export (temporary file until committed) */
+func Create(exportID string) *Writer {
+	writer := &Writer{exportID: exportID}
+	if !manifest.ValidExportID(exportID) {
+		writer.err = errors.New("Invalid export ID: " + exportID)
+		return writer
+	}
+	dir, err := storeDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err == nil {
+		writer.file, err = os.Create(filepath.Join(dir, exportID+".data.tmp"))
+	}
+	writer.err = err
+	return writer
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	// 저장 실패는 반출 응답에 영향을 주지 않음 (commit 시 오류 반환)
+	if w.err == nil {
+		_, w.err = w.file.Write(p)
+	}
+	return len(p), nil
+}
+
+/* [Function] Save artifact of finished export (retention period starts now) */
+func (w *Writer) Commit(artifact *Artifact) error {
+	if w.done {
+		return nil
+	}
+	if w.err != nil {
+		w.Discard()
+		return w.err
+	}
+	w.done = true
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	dir, err := storeDir()
+	if err != nil {
+		return err
+	}
+	artifact.ExportID = w.exportID
+	artifact.CreatedAt = time.Now().UTC()
+	artifact.ExpiresAt = artifact.CreatedAt.Add(Retention())
+	content, err := json.Marshal(artifact)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(w.file.Name(), dataPath(dir, w.exportID)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, w.exportID+".json"), content, 0644)
+}
+
+/* [Function] Remove artifact of failed export */
+func (w *Writer) Discard() {
+	if w.done || w.file == nil {
+		return
+	}
+	w.done = true
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+/* [Function] Get artifact of export and its file path (ErrExpired if expired, os.ErrNotExist if not exists) */
+func Get(requestID string, exportID string) (*Artifact, string, error) {
+	if !manifest.ValidExportID(exportID) {
+		return nil, "", os.ErrNotExist
+	}
+	dir, err := storeDir()
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, exportID+".json"))
+	if err != nil {
+		return nil, "", err
+	}
+	artifact := new(Artifact)
+	if err := json.Unmarshal(content, artifact); err != nil {
+		return nil, "", err
+	}
+	if artifact.RequestID != requestID {
+		return nil, "", os.ErrNotExist
+	}
+	if time.Now().After(artifact.ExpiresAt) {
+		return nil, "", ErrExpired
+	}
+	return artifact, dataPath(dir, exportID), nil
+}
+
+/* [Internal function] Remove expired artifacts and temporary files left by stopped exports */
+func clean() {
+	dir, err := storeDir()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, file := range files {
+		name := file.Name()
+		switch {
+		case strings.HasSuffix(name, ".json"):
+			content, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			artifact := new(Artifact)
+			if json.Unmarshal(content, artifact) != nil || now.After(artifact.ExpiresAt) {
+				exportID := strings.TrimSuffix(name, ".json")
+				os.Remove(dataPath(dir, exportID))
+				os.Remove(filepath.Join(dir, name))
+			}
+		case strings.HasSuffix(name, ".tmp"):
+			// 작성 중인 파일은 보관 기간이 지난 경우에만 삭제
+			if now.Sub(file.ModTime()) > Retention() {
+				os.Remove(filepath.Join(dir, name))
+			}
+		}
+	}
+}
+
+/* [Function] Retention period of artifacts (also applied to finished jobs) */
+func Retention() time.Duration {
+	return time.Duration(configs.Get().Artifact.Retention) * time.Hour
+}
+
+/* [Internal function] Directory of artifacts (relative to the workspace) */
+func storeDir() (string, error) {
+	dir := configs.Get().Artifact.Dir
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	workspace, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(workspace, dir), nil
+}
+
+/* [Internal function] File path of artifact */
+func dataPath(dir string, exportID string) string {
+	return filepath.Join(dir, exportID+".data")
+}
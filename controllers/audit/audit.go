@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Previous hash of the first record
+var genesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// Hash of the record (last field of the line)
+var hashSuffix = regexp.MustCompile(`,"hash":"([0-9a-f]{64})"\}$`)
+
+// Audit record (one JSON line, hash is SHA-256 of the line without the hash field)
+type Record struct {
+	Seq       uint64 `json:"seq"`
+	Time      string `json:"time"`
+	Event     string `json:"event"`
+	RequestID string `json:"requestId"`
+	// API key or user (key=<keyID>, user=<subject>)
+	Identity string `json:"identity"`
+	ClientIP string `json:"clientIp,omitempty"`
+	// Export (rows exported, hash of anonymization options and exported file)
+	ExportID      string `json:"exportId,omitempty"`
+	Rows          uint64 `json:"rows"`
+	OptionsSHA256 string `json:"optionsSha256,omitempty"`
+	SHA256        string `json:"sha256,omitempty"`
+	// Reason of failed or denied request
+	Detail   string `json:"detail,omitempty"`
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// Result of verification (the first broken record if not valid)
+type Verification struct {
+	Valid   bool   `json:"valid"`
+	Records uint64 `json:"records"`
+	// Hash of the last valid record (keep it outside the server to detect truncation)
+	Head       string `json:"head"`
+	BrokenLine int    `json:"brokenLine,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+var (
+	// Last record of the log (loaded from the file before the first append)
+	lastSeq  uint64
+	lastHash string
+	loaded   bool
+	auditMu  sync.Mutex
+)
+
+/* [Function] Append record to the audit log (chained to the previous record) */
+func Append(record *Record) error {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	file, err := logPath()
+	if err != nil {
+		return err
+	}
+	if !loaded {
+		if err := loadLast(file); err != nil {
+			return err
+		}
+		loaded = true
+	}
+	record.Seq = lastSeq + 1
+	record.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	record.PrevHash = lastHash
+	record.Hash = ""
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	record.Hash = hashOf(body)
+	line := append(body[:len(body)-1], []byte(`,"hash":"`+record.Hash+"\"}\n")...)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	// 추가 전용으로 열고 기록 후 동기화
+	out, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := out.Write(line); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	lastSeq = record.Seq
+	lastHash = record.Hash
+	return nil
+}
+
+/* [Function] Verify hash chain of the audit log (empty path is the log of the server) */
+func Verify(file string) (*Verification, error) {
+	if file == "" {
+		var err error
+		if file, err = logPath(); err != nil {
+			return nil, err
+		}
+	}
+	in, size, head, err := snapshot(file)
+	if err != nil {
+		return nil, err
+	}
+	result := &Verification{Valid: true, Head: genesisHash}
+	lineNo := 1
+	// 기록 시점의 크기까지만 확인 (확인 중에도 기록 가능)
+	var reader *bufio.Reader
+	if in != nil {
+		defer in.Close()
+		reader = bufio.NewReader(io.LimitReader(in, size))
+	}
+	for ; reader != nil; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		} else if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if reason := result.check(bytes.TrimSuffix(line, []byte("\n"))); reason != "" {
+			result.Valid = false
+			result.BrokenLine = lineNo
+			result.Reason = reason
+			return result, nil
+		}
+	}
+	// 서버가 마지막으로 기록한 레코드까지 남아 있어야 함 (끝부분 삭제 확인)
+	if head != "" && result.Head != head {
+		result.Valid = false
+		result.BrokenLine = lineNo
+		result.Reason = "Last record does not match the record appended by the server"
+	}
+	return result, nil
+}
+
+/* [Internal function] Open log with its size and last appended hash (taken while no record is being appended, nil file if not exists) */
+func snapshot(file string) (*os.File, int64, string, error) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	head := ""
+	if current, err := logPath(); err == nil && loaded && filepath.Clean(file) == filepath.Clean(current) {
+		head = lastHash
+	}
+	in, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, 0, head, nil
+	} else if err != nil {
+		return nil, 0, "", err
+	}
+	info, err := in.Stat()
+	if err != nil {
+		in.Close()
+		return nil, 0, "", err
+	}
+	return in, info.Size(), head, nil
+}
+
+/* [Internal function] Check one line with the previous record (reason if broken) */
+func (v *Verification) check(line []byte) string {
+	match := hashSuffix.FindSubmatchIndex(line)
+	if match == nil {
+		return "Hash is missing"
+	}
+	body := append(append([]byte{}, line[:match[0]]...), '}')
+	hash := string(line[match[2]:match[3]])
+	record := new(Record)
+	if err := json.Unmarshal(line, record); err != nil {
+		return "Invalid record: " + err.Error()
+	}
+	if record.Seq != v.Records+1 {
+		return "Sequence " + strconv.FormatUint(record.Seq, 10) + " follows " + strconv.FormatUint(v.Records, 10)
+	}
+	if record.PrevHash != v.Head {
+		return "Previous hash does not match record " + strconv.FormatUint(v.Records, 10)
+	}
+	if hashOf(body) != hash {
+		return "Record has been modified"
+	}
+	v.Records = record.Seq
+	v.Head = hash
+	return ""
+}
+
+/* [Internal function] Load sequence and hash of the last record */
+func loadLast(file string) error {
+	lastSeq, lastHash = 0, genesisHash
+	in, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer in.Close()
+	var last []byte
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if last == nil {
+		return nil
+	}
+	record := new(Record)
+	if err := json.Unmarshal(last, record); err != nil || record.Hash == "" {
+		return errors.New("Last record of the audit log is broken: " + file)
+	}
+	lastSeq, lastHash = record.Seq, record.Hash
+	return nil
+}
+
+/* [Internal function] SHA-256 of record (hex) */
+func hashOf(body []byte) string {
+	digest := sha256.Sum256(body)
+	return hex.EncodeToString(digest[:])
+}
+
+/* [Internal function] Path of the audit log */
+func logPath() (string, error) {
+	workspace, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(workspace, "./resources/logs/audit.log"), nil
+}
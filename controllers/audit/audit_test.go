@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// 감사 로그는 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-audit")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestVerify(t *testing.T) {
+	// 기록이 없으면 유효
+	result, err := Verify("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Records != 0 || result.Head != genesisHash {
+		t.Fatalf("empty log = %+v", result)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := Append(&Record{Event: "Success", RequestID: "audit-verify", Identity: "key=0123456789abcdef", Rows: uint64(i * 100)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err = Verify("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Records != 5 || result.Head != lastHash {
+		t.Fatalf("result = %+v", result)
+	}
+	file, _ := logPath()
+	lines := readLines(t, file)
+
+	// 기록 내용 변경
+	modified := append([]string{}, lines...)
+	modified[2] = strings.Replace(modified[2], `"rows":300`, `"rows":3`, 1)
+	checkBroken(t, "modified record", modified, 3, "Record has been modified")
+	// 해시를 다시 계산해도 다음 레코드와 연결되지 않음
+	modified = append([]string{}, lines...)
+	modified[2] = rehash(t, strings.Replace(modified[2], `"rows":300`, `"rows":3`, 1))
+	checkBroken(t, "rehashed record", modified, 4, "Previous hash does not match record 3")
+	// 중간 레코드 삭제
+	removed := append(append([]string{}, lines[:2]...), lines[3:]...)
+	checkBroken(t, "removed line", removed, 3, "Sequence 4 follows 2")
+	// 해시 없는 레코드
+	checkBroken(t, "missing hash", append([]string{`{"seq":1}`}, lines[1:]...), 1, "Hash is missing")
+
+	// 서버 로그의 마지막 레코드 삭제는 서버가 기록한 마지막 해시로 확인
+	if err := ioutil.WriteFile(file, []byte(strings.Join(lines[:4], "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err = Verify("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.BrokenLine != 5 || result.Records != 4 {
+		t.Fatalf("truncated log = %+v", result)
+	}
+	// 다른 파일로 확인하면 기록된 레코드까지만 확인
+	copied := filepath.Join(t.TempDir(), "audit.log")
+	ioutil.WriteFile(copied, []byte(strings.Join(lines[:4], "\n")+"\n"), 0600)
+	if result, err := Verify(copied); err != nil || !result.Valid || result.Records != 4 {
+		t.Fatalf("truncated copy = %+v (%v)", result, err)
+	}
+	// 서버 로그 복구
+	if err := ioutil.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyWhileAppending(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				if err := Append(&Record{Event: "Attempt", RequestID: "audit-concurrent-" + strconv.Itoa(worker)}); err != nil {
+					errs <- err
+				}
+			}
+		}(i)
+		// 확인 중에도 기록되며, 확인은 기록이 끝난 레코드까지만 수행
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				result, err := Verify("")
+				if err != nil {
+					errs <- err
+				} else if !result.Valid {
+					errs <- &verifyError{result}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	result, err := Verify("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Records != lastSeq || result.Head != lastHash {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+// Invalid verification result in concurrent test
+type verifyError struct {
+	result *Verification
+}
+
+func (e *verifyError) Error() string {
+	return "broken at line " + strconv.Itoa(e.result.BrokenLine) + ": " + e.result.Reason
+}
+
+/* [Internal function] Verify copied log and check the broken line */
+func checkBroken(t *testing.T, name string, lines []string, brokenLine int, reason string) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	if err := ioutil.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Verify(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.BrokenLine != brokenLine || result.Reason != reason {
+		t.Errorf("%s: result = %+v", name, result)
+	}
+}
+
+/* [Internal function] Replace hash of the line with the hash of its content */
+func rehash(t *testing.T, line string) string {
+	match := hashSuffix.FindStringSubmatchIndex(line)
+	if match == nil {
+		t.Fatalf("hash is missing: %s", line)
+	}
+	body := line[:match[0]] + "}"
+	return line[:match[2]] + hashOf([]byte(body)) + line[match[3]:]
+}
+
+func readLines(t *testing.T, file string) []string {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(string(bytes.TrimSuffix(content, []byte("\n"))), "\n")
+}
@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const usageText = `Usage:
+  audit verify [file]`
+
+/* [Function] Verify the audit log (error if the hash chain is broken) */
+func Command(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "verify" || len(args) > 2 {
+		return errors.New(usageText)
+	}
+	file := ""
+	if len(args) == 2 {
+		file = args[1]
+	}
+	result, err := Verify(file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Records: "+strconv.FormatUint(result.Records, 10))
+	fmt.Fprintln(out, "Head:    "+result.Head)
+	if !result.Valid {
+		return errors.New("Broken at line " + strconv.Itoa(result.BrokenLine) + ": " + result.Reason)
+	}
+	fmt.Fprintln(out, "Hash chain is valid")
+	return nil
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	// Compression
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+const (
+	// Compression method
+	COMPRESS_NONE = "none"
+	COMPRESS_GZIP = "gzip"
+	COMPRESS_ZSTD = "zstd"
+)
+
+// Formats that are already compressed (not compressed by Accept-Encoding)
+var compressedFormats = map[string]bool{
+	FORMAT_PARQUET: true,
+	FORMAT_XLSX:    true,
+}
+
+// Selected compression
+type Compression struct {
+	Method string
+	// Compressed by Accept-Encoding (client decodes the response, so content type and file name are not changed)
+	ContentEncoding bool
+}
+
+// Compressed output stream (export writer writes into this stream)
+type Compressor struct {
+	Compression
+	out    io.WriteCloser
+	w      io.Writer
+	closed bool
+}
+
+/* [Function] Select compression (compress parameter > Accept-Encoding header) */
+func NegotiateCompression(compress string, acceptEncoding string, format string) (Compression, error) {
+	// compress parameter는 압축 파일(.gz, .zst)로 반출
+	if compress != "" {
+		switch method := strings.ToLower(compress); method {
+		case COMPRESS_NONE, COMPRESS_GZIP, COMPRESS_ZSTD:
+			return Compression{Method: method}, nil
+		default:
+			return Compression{}, errors.New("Unsupported compression: " + compress)
+		}
+	}
+	if compressedFormats[format] {
+		return Compression{Method: COMPRESS_NONE}, nil
+	}
+	// Accept-Encoding 헤더에서 q 값이 가장 큰 방식 선택 (같은 경우 zstd 우선)
+	selected := Compression{Method: COMPRESS_NONE, ContentEncoding: true}
+	selectedQ := 0.0
+	for _, coding := range parseAcceptEncoding(acceptEncoding) {
+		if coding.method != COMPRESS_GZIP && coding.method != COMPRESS_ZSTD {
+			continue
+		}
+		if coding.q > selectedQ || (coding.q == selectedQ && coding.q > 0 && coding.method == COMPRESS_ZSTD) {
+			selected.Method = coding.method
+			selectedQ = coding.q
+		}
+	}
+	if selected.Method == COMPRESS_NONE {
+		selected.ContentEncoding = false
+	}
+	return selected, nil
+}
+
+/* [Function] Whether the content coding (gzip, zstd) is accepted by Accept-Encoding header (q=0 is not acceptable) */
+func AcceptsEncoding(acceptEncoding string, encoding string) bool {
+	encoding = strings.ToLower(encoding)
+	if encoding == "x-gzip" {
+		encoding = COMPRESS_GZIP
+	}
+	// 방식을 직접 지정한 경우가 "*"보다 우선
+	wildcard := false
+	for _, coding := range parseAcceptEncoding(acceptEncoding) {
+		if coding.method == encoding {
+			return coding.q > 0
+		}
+		if coding.method == "*" {
+			wildcard = coding.q > 0
+		}
+	}
+	return wildcard
+}
+
+// Content coding of Accept-Encoding header
+type acceptedCoding struct {
+	method string
+	q      float64
+}
+
+/* [Internal function] Parse Accept-Encoding header (x-gzip is gzip, q is 1 if omitted) */
+func parseAcceptEncoding(acceptEncoding string) []acceptedCoding {
+	var codings []acceptedCoding
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(coding, ";")
+		method := strings.ToLower(strings.TrimSpace(params[0]))
+		if method == "" {
+			continue
+		}
+		if method == "x-gzip" {
+			method = COMPRESS_GZIP
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				parsed, err := strconv.ParseFloat(strings.TrimPrefix(value, "q="), 64)
+				if err != nil {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+		codings = append(codings, acceptedCoding{method: method, q: q})
+	}
+	return codings
+}
+
+/* [Function] Create compressed output stream (compression level is server configuration) */
+func NewCompressor(w io.Writer, compression Compression, config configs.CompressionConfig) (*Compressor, error) {
+	compressor := &Compressor{Compression: compression, w: w}
+	switch compression.Method {
+	case COMPRESS_GZIP:
+		out, err := gzip.NewWriterLevel(w, config.GzipLevel)
+		if err != nil {
+			return nil, err
+		}
+		compressor.out = out
+	case COMPRESS_ZSTD:
+		out, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(config.ZstdLevel)))
+		if err != nil {
+			return nil, err
+		}
+		compressor.out = out
+	case "", COMPRESS_NONE:
+		compressor.Method = COMPRESS_NONE
+	default:
+		return nil, errors.New("Unsupported compression: " + compression.Method)
+	}
+	return compressor, nil
+}
+
+func (c *Compressor) Write(p []byte) (int, error) {
+	if c.out == nil {
+		return c.w.Write(p)
+	}
+	return c.out.Write(p)
+}
+
+/* [Function] Finish compressed stream (response is not closed) */
+func (c *Compressor) Close() error {
+	if c.out == nil || c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.out.Close()
+}
+
+/* [Function] Release compression stream without finishing it (export failed or aborted) */
+func (c *Compressor) Abort() {
+	if c.out == nil || c.closed {
+		return
+	}
+	c.closed = true
+	// zstd encoder는 종료 시 작업 goroutine이 정리되므로 남은 데이터를 버리고 종료
+	if encoder, ok := c.out.(*zstd.Encoder); ok {
+		encoder.Reset(ioutil.Discard)
+		encoder.Close()
+	}
+}
+
+/* [Function] Content type of the compressed file */
+func (c *Compressor) ContentType(contentType string) string {
+	if c.ContentEncoding {
+		return contentType
+	}
+	switch c.Method {
+	case COMPRESS_GZIP:
+		return "application/gzip"
+	case COMPRESS_ZSTD:
+		return "application/zstd"
+	default:
+		return contentType
+	}
+}
+
+/* [Function] Extension appended to the file name (e.g. .csv.gz) */
+func (c *Compressor) Extension() string {
+	if c.ContentEncoding {
+		return ""
+	}
+	switch c.Method {
+	case COMPRESS_GZIP:
+		return ".gz"
+	case COMPRESS_ZSTD:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+/* [Function] Content-Encoding header value (empty if not compressed by Accept-Encoding) */
+func (c *Compressor) ContentEncodingHeader() string {
+	if !c.ContentEncoding || c.Method == COMPRESS_NONE {
+		return ""
+	}
+	return c.Method
+}
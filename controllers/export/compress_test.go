@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	// Compression
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	cases := []struct {
+		compress       string
+		acceptEncoding string
+		format         string
+		expected       Compression
+	}{
+		// Accept-Encoding: q 값이 큰 방식, 같으면 zstd
+		{"", "", FORMAT_CSV, Compression{Method: COMPRESS_NONE}},
+		{"", "gzip", FORMAT_CSV, Compression{Method: COMPRESS_GZIP, ContentEncoding: true}},
+		{"", "gzip, zstd", FORMAT_CSV, Compression{Method: COMPRESS_ZSTD, ContentEncoding: true}},
+		{"", "zstd;q=0.5, gzip;q=0.8", FORMAT_CSV, Compression{Method: COMPRESS_GZIP, ContentEncoding: true}},
+		{"", "x-gzip;q=0.5, deflate, br", FORMAT_JSON, Compression{Method: COMPRESS_GZIP, ContentEncoding: true}},
+		{"", "GZIP ; q=0.3 , zstd ; q=0.3", FORMAT_CSV, Compression{Method: COMPRESS_ZSTD, ContentEncoding: true}},
+		// q=0은 허용하지 않음
+		{"", "zstd;q=0, gzip;q=0.1", FORMAT_CSV, Compression{Method: COMPRESS_GZIP, ContentEncoding: true}},
+		{"", "gzip;q=0, zstd;q=0", FORMAT_CSV, Compression{Method: COMPRESS_NONE}},
+		{"", "gzip;q=invalid", FORMAT_CSV, Compression{Method: COMPRESS_NONE}},
+		{"", "identity, *", FORMAT_CSV, Compression{Method: COMPRESS_NONE}},
+		// 압축된 형식은 Accept-Encoding으로 다시 압축하지 않음
+		{"", "gzip, zstd", FORMAT_PARQUET, Compression{Method: COMPRESS_NONE}},
+		{"", "gzip", FORMAT_XLSX, Compression{Method: COMPRESS_NONE}},
+		// compress parameter가 우선하며 압축 파일로 반출
+		{"zstd", "gzip", FORMAT_CSV, Compression{Method: COMPRESS_ZSTD}},
+		{"GZIP", "", FORMAT_PARQUET, Compression{Method: COMPRESS_GZIP}},
+		{"none", "gzip", FORMAT_CSV, Compression{Method: COMPRESS_NONE}},
+	}
+	for _, c := range cases {
+		compression, err := NegotiateCompression(c.compress, c.acceptEncoding, c.format)
+		if err != nil || compression != c.expected {
+			t.Errorf("compress=%q, Accept-Encoding=%q, format=%s: %+v (%v), want %+v", c.compress, c.acceptEncoding, c.format, compression, err, c.expected)
+		}
+	}
+	if _, err := NegotiateCompression("br", "", FORMAT_CSV); err == nil {
+		t.Error("unsupported compress parameter is accepted")
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	cases := []struct {
+		acceptEncoding string
+		encoding       string
+		expected       bool
+	}{
+		{"gzip", COMPRESS_GZIP, true},
+		{"x-gzip", COMPRESS_GZIP, true},
+		{"gzip", "x-gzip", true},
+		{"gzip", COMPRESS_ZSTD, false},
+		{"", COMPRESS_GZIP, false},
+		{"gzip;q=0", COMPRESS_GZIP, false},
+		{"*", COMPRESS_ZSTD, true},
+		{"*;q=0", COMPRESS_ZSTD, false},
+		// 방식을 직접 지정한 경우가 "*"보다 우선
+		{"zstd;q=0, *", COMPRESS_ZSTD, false},
+		{"*;q=0, zstd;q=0.1", COMPRESS_ZSTD, true},
+	}
+	for _, c := range cases {
+		if accepted := AcceptsEncoding(c.acceptEncoding, c.encoding); accepted != c.expected {
+			t.Errorf("Accept-Encoding=%q, %s: %t", c.acceptEncoding, c.encoding, accepted)
+		}
+	}
+}
+
+func TestCompressorRoundTrip(t *testing.T) {
+	original := strings.Repeat("ID,NAME,AMOUNT\r\n1,홍길동,-12.30\r\n", 1000)
+	config := configs.CompressionConfig{GzipLevel: 6, ZstdLevel: 3}
+	cases := []struct {
+		compression Compression
+		contentType string
+		extension   string
+		encoding    string
+	}{
+		{Compression{Method: COMPRESS_GZIP}, "application/gzip", ".gz", ""},
+		{Compression{Method: COMPRESS_ZSTD}, "application/zstd", ".zst", ""},
+		{Compression{Method: COMPRESS_GZIP, ContentEncoding: true}, "text/csv", "", COMPRESS_GZIP},
+		{Compression{Method: COMPRESS_ZSTD, ContentEncoding: true}, "text/csv", "", COMPRESS_ZSTD},
+		{Compression{Method: COMPRESS_NONE}, "text/csv", "", ""},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		compressor, err := NewCompressor(&buf, c.compression, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 여러 번 나누어 작성
+		for _, chunk := range strings.SplitAfter(original, "\n") {
+			if _, err := io.WriteString(compressor, chunk); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := compressor.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if compressor.ContentType("text/csv") != c.contentType || compressor.Extension() != c.extension || compressor.ContentEncodingHeader() != c.encoding {
+			t.Errorf("%+v: %s %q %q", c.compression, compressor.ContentType("text/csv"), compressor.Extension(), compressor.ContentEncodingHeader())
+		}
+		if c.compression.Method != COMPRESS_NONE && buf.Len() >= len(original) {
+			t.Errorf("%s: compressed %d bytes, original %d bytes", c.compression.Method, buf.Len(), len(original))
+		}
+		var reader io.Reader = &buf
+		switch c.compression.Method {
+		case COMPRESS_GZIP:
+			gzipReader, err := gzip.NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gzipReader
+		case COMPRESS_ZSTD:
+			decoder, err := zstd.NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer decoder.Close()
+			reader = decoder
+		}
+		decompressed, err := ioutil.ReadAll(reader)
+		if err != nil || string(decompressed) != original {
+			t.Errorf("%s: decompressed %d bytes (%v), want %d bytes", c.compression.Method, len(decompressed), err, len(original))
+		}
+	}
+	if _, err := NewCompressor(&bytes.Buffer{}, Compression{Method: "br"}, config); err == nil {
+		t.Error("unsupported compression is accepted")
+	}
+}
@@ -0,0 +1,235 @@
+package export
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	// Encoding
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/transform"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+const (
+	// Quoting policy
+	QUOTE_MINIMAL    = "minimal"
+	QUOTE_ALL        = "all"
+	QUOTE_NONNUMERIC = "nonnumeric"
+	// Line terminator
+	LT_CRLF = "crlf"
+	LT_LF   = "lf"
+	// Encoding
+	ENC_UTF8  = "utf-8"
+	ENC_EUCKR = "euc-kr"
+)
+
+// CSV dialect options
+type CSVOptions struct {
+	Delimiter      string `json:"delimiter"`
+	Quote          string `json:"quote"`
+	LineTerminator string `json:"lineTerminator"`
+	BOM            bool   `json:"bom"`
+	Encoding       string `json:"encoding"`
+	// Text written for NULL (empty string value is quoted when NULL is empty)
+	NullValue string `json:"nullValue"`
+}
+
+// CSV writer (RFC 4180)
+type csvWriter struct {
+	out       *bufio.Writer
+	closer    io.Closer
+	options   CSVOptions
+	delimiter rune
+	newline   string
+}
+
+/* [Function] Override CSV options by query parameters (delimiter, quote, lineTerminator, bom, encoding, null) */
+func (o *CSVOptions) Merge(params url.Values) error {
+	if value := params.Get("delimiter"); value != "" {
+		// 탭 문자는 query parameter로 전달하기 어려우므로 별도 표기 허용
+		if value == "tab" {
+			value = "\t"
+		}
+		o.Delimiter = value
+	}
+	if value := params.Get("quote"); value != "" {
+		o.Quote = value
+	}
+	if value := params.Get("lineTerminator"); value != "" {
+		o.LineTerminator = value
+	}
+	if value := params.Get("bom"); value != "" {
+		bom, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("Invalid bom parameter: " + value)
+		}
+		o.BOM = bom
+	}
+	if value := params.Get("encoding"); value != "" {
+		o.Encoding = value
+	}
+	if values, ok := params["null"]; ok && len(values) > 0 {
+		o.NullValue = values[0]
+	}
+	return nil
+}
+
+/* [Function] Create CSV writer */
+func NewCSVWriter(w io.Writer, options CSVOptions) (Writer, error) {
+	// 기본값 설정
+	if options.Delimiter == "" {
+		options.Delimiter = ","
+	}
+	if options.Quote == "" {
+		options.Quote = QUOTE_MINIMAL
+	}
+	if options.LineTerminator == "" {
+		options.LineTerminator = LT_CRLF
+	}
+	options.Encoding = strings.ToLower(options.Encoding)
+	if options.Encoding == "" || options.Encoding == "utf8" {
+		options.Encoding = ENC_UTF8
+	}
+
+	writer := &csvWriter{options: options}
+	// 구분자 검증 (한 문자, 따옴표 및 줄바꿈 문자 불가)
+	delimiter, size := utf8.DecodeRuneInString(options.Delimiter)
+	if size != len(options.Delimiter) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' || delimiter == utf8.RuneError {
+		return nil, errors.New("Invalid CSV delimiter: " + options.Delimiter)
+	}
+	writer.delimiter = delimiter
+	// NULL 표기는 따옴표 없이 작성되므로 구분자, 따옴표, 줄바꿈 문자 불가
+	if strings.ContainsRune(options.NullValue, delimiter) || strings.ContainsAny(options.NullValue, "\"\r\n") {
+		return nil, errors.New("Invalid CSV null value: " + options.NullValue)
+	}
+	// 따옴표 처리 방식 검증
+	switch options.Quote {
+	case QUOTE_MINIMAL, QUOTE_ALL, QUOTE_NONNUMERIC:
+	default:
+		return nil, errors.New("Invalid CSV quote policy: " + options.Quote)
+	}
+	// 줄바꿈 문자
+	switch options.LineTerminator {
+	case LT_CRLF:
+		writer.newline = "\r\n"
+	case LT_LF:
+		writer.newline = "\n"
+	default:
+		return nil, errors.New("Invalid CSV line terminator: " + options.LineTerminator)
+	}
+	// 인코딩 (EUC-KR로 표현할 수 없는 문자는 대체 문자로 변환)
+	switch options.Encoding {
+	case ENC_UTF8:
+		writer.out = bufio.NewWriter(w)
+		if options.BOM {
+			writer.out.WriteString("\uFEFF")
+		}
+	case ENC_EUCKR, "cp949":
+		encoded := transform.NewWriter(w, encoding.ReplaceUnsupported(korean.EUCKR.NewEncoder()))
+		writer.out = bufio.NewWriter(encoded)
+		writer.closer = encoded
+		writer.options.Encoding = ENC_EUCKR
+	default:
+		return nil, errors.New("Unsupported CSV encoding: " + options.Encoding)
+	}
+	return writer, nil
+}
+
+func (w *csvWriter) ContentType() string {
+	return "text/csv; charset=" + w.options.Encoding
+}
+
+func (w *csvWriter) Extension() string {
+	return ".csv"
+}
+
+func (w *csvWriter) WriteHeader(header []string) error {
+	for i, field := range header {
+		if i > 0 {
+			w.out.WriteRune(w.delimiter)
+		}
+		w.writeField(field, w.options.Quote != QUOTE_MINIMAL || w.needsQuotes(field))
+	}
+	_, err := w.out.WriteString(w.newline)
+	return err
+}
+
+func (w *csvWriter) WriteRow(row query.Row) error {
+	for i, value := range row {
+		if i > 0 {
+			w.out.WriteRune(w.delimiter)
+		}
+		// NULL은 따옴표 없이 작성하여 빈 문자열("")과 구분
+		if value == nil {
+			w.out.WriteString(w.options.NullValue)
+			continue
+		}
+		field := query.ValueString(value)
+		quote := false
+		switch w.options.Quote {
+		case QUOTE_ALL:
+			quote = true
+		case QUOTE_NONNUMERIC:
+			quote = !isNumeric(value)
+		}
+		// NULL 표기와 같은 값(기본값은 빈 문자열)은 따옴표로 구분
+		if !quote {
+			quote = w.needsQuotes(field) || field == w.options.NullValue
+		}
+		w.writeField(field, quote)
+	}
+	_, err := w.out.WriteString(w.newline)
+	return err
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.out.Flush(); err != nil {
+		return err
+	}
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+/* [Internal function] Write one field */
+func (w *csvWriter) writeField(field string, quote bool) {
+	if !quote {
+		w.out.WriteString(field)
+		return
+	}
+	// 필드 내 따옴표는 두 번 반복하여 표현
+	w.out.WriteByte('"')
+	w.out.WriteString(strings.ReplaceAll(field, "\"", "\"\""))
+	w.out.WriteByte('"')
+}
+
+/* [Internal function] Check whether the field must be quoted (delimiter, quotation, line break, leading or trailing space) */
+func (w *csvWriter) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if strings.ContainsRune(field, w.delimiter) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(field)
+	last, _ := utf8.DecodeLastRuneInString(field)
+	return first == ' ' || first == '\t' || last == ' ' || last == '\t'
+}
+
+/* [Internal function] Check numeric type (not quoted in nonnumeric policy) */
+func isNumeric(value interface{}) bool {
+	switch value.(type) {
+	case int64, float64, query.Decimal:
+		return true
+	default:
+		return false
+	}
+}
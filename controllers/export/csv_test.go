@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+/* [Internal function] Write header and rows with CSV options */
+func writeCSV(t *testing.T, options CSVOptions, header []string, rows ...query.Row) string {
+	var buf bytes.Buffer
+	writer, err := NewCSVWriter(&buf, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCSVQuoting(t *testing.T) {
+	row := query.Row{"plain", "a,b", `say "hi"`, "line\nbreak", "cr\rx", " lead", "trail\t", int64(7), 1.5, query.Decimal("-12.30"), true}
+	header := []string{"A", "B,C"}
+	cases := []struct {
+		quote    string
+		expected string
+	}{
+		{QUOTE_MINIMAL, "A,\"B,C\"\r\n" + `plain,"a,b","say ""hi""","line` + "\nbreak\",\"cr\rx\",\" lead\",\"trail\t\",7,1.5,-12.30,true\r\n"},
+		{QUOTE_ALL, "\"A\",\"B,C\"\r\n" + `"plain","a,b","say ""hi""","line` + "\nbreak\",\"cr\rx\",\" lead\",\"trail\t\",\"7\",\"1.5\",\"-12.30\",\"true\"\r\n"},
+		{QUOTE_NONNUMERIC, "\"A\",\"B,C\"\r\n" + `"plain","a,b","say ""hi""","line` + "\nbreak\",\"cr\rx\",\" lead\",\"trail\t\",7,1.5,-12.30,\"true\"\r\n"},
+	}
+	for _, c := range cases {
+		if got := writeCSV(t, CSVOptions{Quote: c.quote}, header, row); got != c.expected {
+			t.Errorf("%s:\n got %q\nwant %q", c.quote, got, c.expected)
+		}
+	}
+	// 구분자와 줄바꿈 문자
+	got := writeCSV(t, CSVOptions{Delimiter: ";", LineTerminator: LT_LF}, []string{"A", "B"}, query.Row{"a,b", "c;d"})
+	if got != "A;B\na,b;\"c;d\"\n" {
+		t.Errorf("delimiter: %q", got)
+	}
+}
+
+func TestCSVNull(t *testing.T) {
+	// NULL은 따옴표 없이, 빈 문자열은 따옴표로 구분
+	got := writeCSV(t, CSVOptions{}, []string{"A", "B", "C"}, query.Row{"a", nil, ""})
+	if got != "A,B,C\r\na,,\"\"\r\n" {
+		t.Errorf("default null: %q", got)
+	}
+	// NULL 표기와 같은 문자열은 따옴표로 구분
+	got = writeCSV(t, CSVOptions{NullValue: "NULL", Quote: QUOTE_ALL}, []string{"A", "B", "C"}, query.Row{nil, "NULL", ""})
+	if got != "\"A\",\"B\",\"C\"\r\nNULL,\"NULL\",\"\"\r\n" {
+		t.Errorf("null value: %q", got)
+	}
+	got = writeCSV(t, CSVOptions{NullValue: `\N`}, []string{"A", "B"}, query.Row{nil, ""})
+	if got != "A,B\r\n\\N,\r\n" {
+		t.Errorf("\\N null value: %q", got)
+	}
+	// NULL 표기에는 구분자, 따옴표, 줄바꿈 문자 불가
+	for _, nullValue := range []string{",", `"`, "a\nb"} {
+		if _, err := NewCSVWriter(&bytes.Buffer{}, CSVOptions{NullValue: nullValue}); err == nil {
+			t.Errorf("null value %q is accepted", nullValue)
+		}
+	}
+}
+
+func TestCSVBOMAndEncoding(t *testing.T) {
+	if got := writeCSV(t, CSVOptions{BOM: true}, []string{"이름"}); got != "\uFEFF이름\r\n" {
+		t.Errorf("BOM: %q", got)
+	}
+	if got := writeCSV(t, CSVOptions{}, []string{"이름"}); got != "이름\r\n" {
+		t.Errorf("no BOM: %q", got)
+	}
+	// EUC-KR은 BOM을 사용하지 않음
+	got := writeCSV(t, CSVOptions{BOM: true, Encoding: "EUC-KR"}, []string{"이름"})
+	if got != "\xc0\xcc\xb8\xa7\r\n" {
+		t.Errorf("EUC-KR: %q", got)
+	}
+	writer, _ := NewCSVWriter(&bytes.Buffer{}, CSVOptions{Encoding: "cp949"})
+	if writer.ContentType() != "text/csv; charset=euc-kr" {
+		t.Errorf("content type = %s", writer.ContentType())
+	}
+}
+
+func TestCSVInvalidOptions(t *testing.T) {
+	for _, options := range []CSVOptions{
+		{Delimiter: `"`},
+		{Delimiter: "\n"},
+		{Delimiter: ",,"},
+		{Quote: "never"},
+		{LineTerminator: "cr"},
+		{Encoding: "latin1"},
+	} {
+		if _, err := NewCSVWriter(&bytes.Buffer{}, options); err == nil {
+			t.Errorf("options %+v are accepted", options)
+		}
+	}
+}
+
+func TestCSVMerge(t *testing.T) {
+	options := CSVOptions{Delimiter: ";", NullValue: "NULL"}
+	params := url.Values{"delimiter": {"tab"}, "bom": {"true"}, "null": {""}, "quote": {QUOTE_ALL}}
+	if err := options.Merge(params); err != nil {
+		t.Fatal(err)
+	}
+	if options.Delimiter != "\t" || !options.BOM || options.NullValue != "" || options.Quote != QUOTE_ALL {
+		t.Errorf("merged options = %+v", options)
+	}
+	if err := options.Merge(url.Values{"bom": {"maybe"}}); err == nil {
+		t.Error("invalid bom is accepted")
+	}
+}
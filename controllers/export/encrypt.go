@@ -0,0 +1,240 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Encryption
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	ageArmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgpArmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+const (
+	// Encryption type
+	ENCRYPT_NONE    = ""
+	ENCRYPT_AGE     = "age"
+	ENCRYPT_OPENPGP = "openpgp"
+)
+
+// Encryption options (query.json > export > encryption)
+type EncryptionOptions struct {
+	Type string `json:"type"`
+	// Recipient public keys: key text (age1..., ssh-ed25519 ..., -----BEGIN PGP PUBLIC KEY BLOCK-----) or key file path relative to the request directory
+	Recipients []string `json:"recipients"`
+	// ASCII armored output (.asc for OpenPGP)
+	Armor bool `json:"armor"`
+}
+
+// Encrypted output stream (compressed stream writes into this stream)
+type Encryptor struct {
+	options EncryptionOptions
+	// Parsed recipients
+	ageRecipients []age.Recipient
+	pgpRecipients openpgp.EntityList
+	w             io.Writer
+	// Armor and encryption writers (created at the first write)
+	armor io.WriteCloser
+	out   io.WriteCloser
+}
+
+/* [Internal function] Load recipient key files (entries that are not key text are file paths in the request directory) */
+func (o *EncryptionOptions) loadRecipients(dir string) error {
+	for i, recipient := range o.Recipients {
+		recipient = strings.TrimSpace(recipient)
+		if strings.HasPrefix(recipient, "age1") || strings.HasPrefix(recipient, "ssh-") || strings.HasPrefix(recipient, "-----BEGIN") {
+			o.Recipients[i] = recipient
+			continue
+		}
+		// 요청 디렉토리 밖의 파일은 참조할 수 없음
+		file := filepath.Join(dir, filepath.Clean("/"+recipient))
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return errors.New("Failed to read recipient key: " + recipient)
+		}
+		o.Recipients[i] = string(content)
+	}
+	return nil
+}
+
+/* [Function] Create encrypted output stream (output is not encrypted if encryption is not declared) */
+func NewEncryptor(w io.Writer, options EncryptionOptions) (*Encryptor, error) {
+	encryptor := &Encryptor{options: options, w: w}
+	encryptor.options.Type = strings.ToLower(options.Type)
+	// 수신자가 선언된 경우 암호화 방식은 필수
+	if len(options.Recipients) == 0 {
+		if encryptor.options.Type != ENCRYPT_NONE {
+			return nil, errors.New("Encryption recipients are not declared")
+		}
+		return encryptor, nil
+	}
+	switch encryptor.options.Type {
+	case ENCRYPT_AGE:
+		for _, recipient := range options.Recipients {
+			recipients, err := parseAgeRecipients(recipient)
+			if err != nil {
+				return nil, err
+			}
+			encryptor.ageRecipients = append(encryptor.ageRecipients, recipients...)
+		}
+	case ENCRYPT_OPENPGP:
+		for _, recipient := range options.Recipients {
+			entities, err := parsePGPRecipients(recipient)
+			if err != nil {
+				return nil, err
+			}
+			encryptor.pgpRecipients = append(encryptor.pgpRecipients, entities...)
+		}
+	default:
+		return nil, errors.New("Unsupported encryption type: " + options.Type)
+	}
+	return encryptor, nil
+}
+
+/* [Function] Whether the output is encrypted */
+func (e *Encryptor) Enabled() bool {
+	return len(e.ageRecipients) > 0 || len(e.pgpRecipients) > 0
+}
+
+func (e *Encryptor) Write(p []byte) (int, error) {
+	if !e.Enabled() {
+		return e.w.Write(p)
+	}
+	// 암호화 헤더는 응답 헤더가 설정된 이후에 작성
+	if e.out == nil {
+		if err := e.begin(); err != nil {
+			return 0, err
+		}
+	}
+	return e.out.Write(p)
+}
+
+/* [Function] Finish encrypted stream (response is not closed) */
+func (e *Encryptor) Close() error {
+	if !e.Enabled() {
+		return nil
+	}
+	if e.out == nil {
+		if err := e.begin(); err != nil {
+			return err
+		}
+	}
+	if err := e.out.Close(); err != nil {
+		return err
+	}
+	if e.armor != nil {
+		return e.armor.Close()
+	}
+	return nil
+}
+
+/* [Function] Encryption type (empty if not encrypted) */
+func (e *Encryptor) Type() string {
+	if !e.Enabled() {
+		return ENCRYPT_NONE
+	}
+	return e.options.Type
+}
+
+/* [Function] Content type of the encrypted file */
+func (e *Encryptor) ContentType(contentType string) string {
+	if !e.Enabled() {
+		return contentType
+	}
+	if e.options.Type == ENCRYPT_OPENPGP {
+		return "application/pgp-encrypted"
+	}
+	return "application/octet-stream"
+}
+
+/* [Function] Extension appended to the file name (e.g. .csv.gz.age) */
+func (e *Encryptor) Extension() string {
+	if !e.Enabled() {
+		return ""
+	}
+	if e.options.Type == ENCRYPT_AGE {
+		return ".age"
+	}
+	if e.options.Armor {
+		return ".asc"
+	}
+	return ".gpg"
+}
+
+/* [Internal function] Begin encryption (write recipient header) */
+func (e *Encryptor) begin() error {
+	var err error
+	switch e.options.Type {
+	case ENCRYPT_AGE:
+		dst := e.w
+		if e.options.Armor {
+			e.armor = ageArmor.NewWriter(e.w)
+			dst = e.armor
+		}
+		e.out, err = age.Encrypt(dst, e.ageRecipients...)
+	case ENCRYPT_OPENPGP:
+		dst := e.w
+		if e.options.Armor {
+			if e.armor, err = pgpArmor.Encode(e.w, "PGP MESSAGE", nil); err != nil {
+				return err
+			}
+			dst = e.armor
+		}
+		e.out, err = openpgp.Encrypt(dst, e.pgpRecipients, nil, &openpgp.FileHints{IsBinary: true}, nil)
+	}
+	return err
+}
+
+/* [Internal function] Parse age recipients (X25519 or SSH public key, one per line) */
+func parseAgeRecipients(text string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var recipient age.Recipient
+		var err error
+		if strings.HasPrefix(line, "ssh-") {
+			recipient, err = agessh.ParseRecipient(line)
+		} else {
+			recipient, err = age.ParseX25519Recipient(line)
+		}
+		if err != nil {
+			return nil, errors.New("Invalid age recipient: " + err.Error())
+		}
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("Invalid age recipient: no recipients found")
+	}
+	return recipients, nil
+}
+
+/* [Internal function] Parse OpenPGP public keys (armored or binary) that have a valid encryption key */
+func parsePGPRecipients(text string) (openpgp.EntityList, error) {
+	var entities openpgp.EntityList
+	var err error
+	if strings.Contains(text, "-----BEGIN PGP") {
+		entities, err = openpgp.ReadArmoredKeyRing(strings.NewReader(text))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader([]byte(text)))
+	}
+	if err != nil {
+		return nil, errors.New("Invalid OpenPGP recipient: " + err.Error())
+	}
+	now := time.Now()
+	for _, entity := range entities {
+		if _, ok := entity.EncryptionKey(now); !ok {
+			return nil, errors.New("Invalid OpenPGP recipient: no valid encryption key")
+		}
+	}
+	return entities, nil
+}
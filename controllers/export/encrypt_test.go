@@ -0,0 +1,177 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	// Encryption
+	"filippo.io/age"
+	ageArmor "filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgpArmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// Plaintext of round-trip test
+const plaintext = "ID,NAME\r\n1,홍길동\r\n"
+
+/* [Internal function] Encrypt plaintext with encryption options */
+func encrypt(t *testing.T, options EncryptionOptions) []byte {
+	var buf bytes.Buffer
+	encryptor, err := NewEncryptor(&buf, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encryptor.Enabled() || encryptor.Type() != options.Type {
+		t.Fatalf("type = %q", encryptor.Type())
+	}
+	// 나누어 기록한 데이터도 하나의 메시지로 암호화
+	for _, part := range []string{plaintext[:5], plaintext[5:]} {
+		if _, err := encryptor.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encryptor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("NAME")) {
+		t.Fatal("output contains plaintext")
+	}
+	return buf.Bytes()
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := age.GenerateX25519Identity()
+	// 수신자 파일은 한 줄에 하나씩, 주석 허용
+	recipients := "# recipients\n" + identity.Recipient().String() + "\n" + other.Recipient().String() + "\n"
+	for _, armor := range []bool{false, true} {
+		data := encrypt(t, EncryptionOptions{Type: ENCRYPT_AGE, Recipients: []string{recipients}, Armor: armor})
+		var r io.Reader = bytes.NewReader(data)
+		if armor {
+			if !strings.HasPrefix(string(data), ageArmor.Header) {
+				t.Fatalf("armored output = %q", data[:20])
+			}
+			r = ageArmor.NewReader(r)
+		}
+		decrypted, err := age.Decrypt(r, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content, err := ioutil.ReadAll(decrypted); err != nil || string(content) != plaintext {
+			t.Fatalf("armor %v: decrypted = %q (%v)", armor, content, err)
+		}
+	}
+}
+
+func TestOpenPGPRoundTrip(t *testing.T) {
+	entity, err := openpgp.NewEntity("DEMS", "test", "dems@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 공개키 (binary, armored)
+	var binaryKey, armoredKey bytes.Buffer
+	if err := entity.Serialize(&binaryKey); err != nil {
+		t.Fatal(err)
+	}
+	w, err := pgpArmor.Encode(&armoredKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(binaryKey.Bytes())
+	w.Close()
+
+	for _, key := range []string{binaryKey.String(), armoredKey.String()} {
+		for _, armor := range []bool{false, true} {
+			data := encrypt(t, EncryptionOptions{Type: ENCRYPT_OPENPGP, Recipients: []string{key}, Armor: armor})
+			var r io.Reader = bytes.NewReader(data)
+			if armor {
+				block, err := pgpArmor.Decode(r)
+				if err != nil || block.Type != "PGP MESSAGE" {
+					t.Fatalf("armored output: %v", err)
+				}
+				r = block.Body
+			}
+			message, err := openpgp.ReadMessage(r, openpgp.EntityList{entity}, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if content, err := ioutil.ReadAll(message.UnverifiedBody); err != nil || string(content) != plaintext {
+				t.Fatalf("armor %v: decrypted = %q (%v)", armor, content, err)
+			}
+		}
+	}
+}
+
+func TestInvalidRecipients(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	recipient := identity.Recipient().String()
+	cases := []EncryptionOptions{
+		// 수신자만 선언하고 방식이 없거나 지원하지 않는 방식
+		{Recipients: []string{recipient}},
+		{Type: "rsa", Recipients: []string{recipient}},
+		// 방식만 선언하고 수신자가 없음
+		{Type: ENCRYPT_AGE},
+		// 잘못된 키
+		{Type: ENCRYPT_AGE, Recipients: []string{"age1invalid"}},
+		{Type: ENCRYPT_AGE, Recipients: []string{"# comment only\n"}},
+		{Type: ENCRYPT_OPENPGP, Recipients: []string{"-----BEGIN PGP PUBLIC KEY BLOCK-----\n\ninvalid\n-----END PGP PUBLIC KEY BLOCK-----\n"}},
+		{Type: ENCRYPT_OPENPGP, Recipients: []string{"not a key"}},
+		{Type: ENCRYPT_OPENPGP, Recipients: []string{recipient}},
+	}
+	for i, options := range cases {
+		var buf bytes.Buffer
+		if _, err := NewEncryptor(&buf, options); err == nil {
+			t.Errorf("case %d: options %+v are accepted", i, options)
+		}
+		// 평문이 기록되기 전에 실패
+		if buf.Len() != 0 {
+			t.Errorf("case %d: %d bytes are written", i, buf.Len())
+		}
+	}
+	// 암호화하지 않는 경우 그대로 기록
+	var buf bytes.Buffer
+	encryptor, err := NewEncryptor(&buf, EncryptionOptions{})
+	if err != nil || encryptor.Enabled() || encryptor.Extension() != "" {
+		t.Fatalf("encryptor without recipients: %v", err)
+	}
+	encryptor.Write([]byte(plaintext))
+	if encryptor.Close() != nil || buf.String() != plaintext {
+		t.Fatalf("output = %q", buf.String())
+	}
+}
+
+func TestRecipientFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dems-recipients")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	identity, _ := age.GenerateX25519Identity()
+	if err := ioutil.WriteFile(filepath.Join(dir, "recipient.txt"), []byte(identity.Recipient().String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	options := EncryptionOptions{Type: ENCRYPT_AGE, Recipients: []string{"recipient.txt"}}
+	if err := options.loadRecipients(dir); err != nil {
+		t.Fatal(err)
+	}
+	data := encrypt(t, options)
+	decrypted, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadAll(decrypted); string(content) != plaintext {
+		t.Fatalf("decrypted = %q", content)
+	}
+	// 요청 디렉토리 밖의 파일은 참조할 수 없음
+	options = EncryptionOptions{Type: ENCRYPT_AGE, Recipients: []string{"../" + filepath.Base(dir) + "/recipient.txt"}}
+	if err := options.loadRecipients(filepath.Join(dir, "request")); err == nil {
+		t.Fatal("file outside the request directory is loaded")
+	}
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+// JSON writer (JSON Lines or JSON array of objects keyed by header)
+type jsonWriter struct {
+	out   *bufio.Writer
+	lines bool
+	keys  [][]byte
+	nRows uint64
+}
+
+/* [Function] Create JSON Lines writer (one object per line) */
+func NewJSONLinesWriter(w io.Writer) Writer {
+	return &jsonWriter{out: bufio.NewWriter(w), lines: true}
+}
+
+/* [Function] Create JSON array writer */
+func NewJSONArrayWriter(w io.Writer) Writer {
+	return &jsonWriter{out: bufio.NewWriter(w)}
+}
+
+func (w *jsonWriter) ContentType() string {
+	if w.lines {
+		return "application/x-ndjson"
+	}
+	return "application/json"
+}
+
+func (w *jsonWriter) Extension() string {
+	if w.lines {
+		return ".jsonl"
+	}
+	return ".json"
+}
+
+func (w *jsonWriter) WriteHeader(header []string) error {
+	// 행마다 반복되는 key는 미리 인코딩
+	w.keys = make([][]byte, len(header))
+	for i, column := range header {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		w.keys[i] = key
+	}
+	if !w.lines {
+		_, err := w.out.WriteString("[")
+		return err
+	}
+	return nil
+}
+
+func (w *jsonWriter) WriteRow(row query.Row) error {
+	if len(row) != len(w.keys) {
+		return errors.New("Number of fields does not match header")
+	}
+	// 배열 형식은 행 사이에 구분자 추가
+	if !w.lines {
+		if w.nRows > 0 {
+			w.out.WriteString(",")
+		}
+		w.out.WriteString("\n")
+	}
+	// 헤더 순서대로 객체 생성
+	w.out.WriteByte('{')
+	for i, value := range row {
+		if i > 0 {
+			w.out.WriteByte(',')
+		}
+		encoded, err := encodeValue(value)
+		if err != nil {
+			return err
+		}
+		w.out.Write(w.keys[i])
+		w.out.WriteByte(':')
+		w.out.Write(encoded)
+	}
+	w.out.WriteByte('}')
+	w.nRows++
+	if w.lines {
+		_, err := w.out.WriteString("\n")
+		return err
+	}
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	if !w.lines {
+		if w.nRows > 0 {
+			w.out.WriteString("\n")
+		}
+		w.out.WriteString("]\n")
+	}
+	return w.out.Flush()
+}
+
+/* [Internal function] Encode value as JSON (NULL is null, numeric type is number) */
+func encodeValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case int64:
+		return strconv.AppendInt(nil, v, 10), nil
+	case float64:
+		// JSON은 NaN, Infinity를 표현할 수 없으므로 문자열로 작성
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return json.Marshal(query.ValueString(v))
+		}
+		return json.Marshal(v)
+	case bool:
+		return strconv.AppendBool(nil, v), nil
+	case query.Decimal:
+		// 정밀도 유지를 위해 decimal 문자열을 그대로 숫자로 작성
+		return json.Marshal(json.Number(v))
+	case time.Time:
+		return json.Marshal(v.Format(time.RFC3339Nano))
+	default:
+		return json.Marshal(query.ValueString(v))
+	}
+}
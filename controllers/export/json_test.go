@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+// Header and rows of JSON test (NULL, number, decimal, time, string)
+var (
+	jsonHeader = []string{"ID", "NAME", "SCORE", "ACTIVE", "AMOUNT", "CREATED"}
+	jsonRows   = []query.Row{
+		{int64(1), "홍길동", 1.5, true, query.Decimal("12345678901234567890.123456789"), time.Date(2024, 3, 1, 9, 30, 15, 123456000, time.UTC)},
+		{nil, "a\"b\n", math.NaN(), false, query.Decimal("-0.050"), nil},
+	}
+)
+
+/* [Internal function] Write header and rows with JSON writer */
+func writeJSON(t *testing.T, create func(io.Writer) Writer, rows ...query.Row) string {
+	var buf bytes.Buffer
+	writer := create(&buf)
+	if err := writer.WriteHeader(jsonHeader); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+/* [Internal function] Decode object keeping numbers as written */
+func decodeObject(t *testing.T, data string) map[string]interface{} {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	object := make(map[string]interface{})
+	if err := decoder.Decode(&object); err != nil {
+		t.Fatalf("%v: %s", err, data)
+	}
+	return object
+}
+
+func TestJSONValues(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(writeJSON(t, NewJSONLinesWriter, jsonRows...), "\n"), "\n")
+	if len(lines) != len(jsonRows) {
+		t.Fatalf("lines = %q", lines)
+	}
+	// 컬럼 순서대로 작성
+	if expected := `{"ID":1,"NAME":"홍길동","SCORE":1.5,"ACTIVE":true,"AMOUNT":12345678901234567890.123456789,"CREATED":"2024-03-01T09:30:15.123456Z"}`; lines[0] != expected {
+		t.Fatalf("line = %s, want %s", lines[0], expected)
+	}
+	first := decodeObject(t, lines[0])
+	// decimal은 정밀도를 유지한 숫자
+	if amount, ok := first["AMOUNT"].(json.Number); !ok || amount.String() != "12345678901234567890.123456789" {
+		t.Errorf("AMOUNT = %#v", first["AMOUNT"])
+	}
+	second := decodeObject(t, lines[1])
+	// NULL은 null, NaN은 문자열
+	for _, key := range []string{"ID", "CREATED"} {
+		if value, ok := second[key]; !ok || value != nil {
+			t.Errorf("%s = %#v, want null", key, value)
+		}
+	}
+	if second["NAME"] != "a\"b\n" || second["SCORE"] != "NaN" || second["ACTIVE"] != false {
+		t.Errorf("row = %#v", second)
+	}
+	if amount, ok := second["AMOUNT"].(json.Number); !ok || amount.String() != "-0.050" {
+		t.Errorf("AMOUNT = %#v", second["AMOUNT"])
+	}
+}
+
+func TestJSONFraming(t *testing.T) {
+	// JSON Lines: 한 줄에 객체 하나, 데이터가 없으면 빈 파일
+	if output := writeJSON(t, NewJSONLinesWriter); output != "" {
+		t.Errorf("empty JSON Lines = %q", output)
+	}
+	lines := writeJSON(t, NewJSONLinesWriter, jsonRows...)
+	if strings.Count(lines, "\n") != len(jsonRows) || strings.HasPrefix(lines, "[") {
+		t.Errorf("JSON Lines = %q", lines)
+	}
+	// 배열: 전체가 하나의 JSON 값
+	if output := writeJSON(t, NewJSONArrayWriter); output != "[]\n" {
+		t.Errorf("empty array = %q", output)
+	}
+	array := writeJSON(t, NewJSONArrayWriter, jsonRows...)
+	if !strings.HasPrefix(array, "[\n{") || !strings.HasSuffix(array, "}\n]\n") {
+		t.Errorf("array = %q", array)
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal([]byte(array), &objects); err != nil || len(objects) != len(jsonRows) {
+		t.Fatalf("array objects = %d (%v)", len(objects), err)
+	}
+	// 같은 행은 형식과 관계없이 같은 객체
+	for i, line := range strings.Split(strings.TrimSuffix(lines, "\n"), "\n") {
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &object); err != nil || !reflect.DeepEqual(object, objects[i]) {
+			t.Errorf("row %d: %v, array %v (%v)", i, object, objects[i], err)
+		}
+	}
+
+	writer := NewJSONArrayWriter(&bytes.Buffer{})
+	if writer.ContentType() != "application/json" || writer.Extension() != ".json" {
+		t.Errorf("array: %s %s", writer.ContentType(), writer.Extension())
+	}
+	writer = NewJSONLinesWriter(&bytes.Buffer{})
+	if writer.ContentType() != "application/x-ndjson" || writer.Extension() != ".jsonl" {
+		t.Errorf("JSON Lines: %s %s", writer.ContentType(), writer.Extension())
+	}
+	writer.WriteHeader([]string{"A"})
+	if err := writer.WriteRow(query.Row{int64(1), int64(2)}); err == nil {
+		t.Error("row with more fields than header is accepted")
+	}
+}
@@ -0,0 +1,331 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	// Parquet writer
+	parquet "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/encoding"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+const (
+	// Default number of rows per row group
+	parquetRowGroupSize = 65536
+	// Row group is also written when buffered values exceed this size (memory bound)
+	parquetMaxRowGroupBytes = 64 * 1024 * 1024
+)
+
+// Parquet options (query.json > export > parquet)
+type ParquetOptions struct {
+	// Number of rows per row group
+	RowGroupSize int `json:"rowGroupSize"`
+	// Compression codec (snappy, none)
+	Compression string `json:"compression"`
+}
+
+// Parquet writer (rows are buffered by parquet-go and written in row groups)
+type parquetWriter struct {
+	out       io.Writer
+	groupSize int
+	codec     compress.Codec
+	// Source column types (set before header)
+	types   []query.ColumnInfo
+	columns []parquetColumn
+	writer  *parquet.Writer
+	// Buffered rows and size of values in current row group
+	nRows         int
+	bufferedBytes int
+}
+
+// Column of the file schema
+type parquetColumn struct {
+	name  string
+	index int
+	info  query.ColumnInfo
+	// Byte length of FIXED_LEN_BYTE_ARRAY decimal (0 if INT64)
+	typeLength int
+}
+
+// Root group of the file schema (columns in header order, parquet.Group sorts fields by name)
+type parquetGroup []parquet.Field
+
+// Named column of root group
+type parquetField struct {
+	parquet.Node
+	name string
+}
+
+/* [Function] Create Parquet writer */
+func NewParquetWriter(w io.Writer, options ParquetOptions) (Writer, error) {
+	writer := &parquetWriter{out: w, groupSize: options.RowGroupSize}
+	if writer.groupSize == 0 {
+		writer.groupSize = parquetRowGroupSize
+	} else if writer.groupSize < 0 {
+		return nil, errors.New("Invalid Parquet row group size: " + strconv.Itoa(options.RowGroupSize))
+	}
+	switch strings.ToLower(options.Compression) {
+	case "", "snappy":
+		writer.codec = &parquet.Snappy
+	case "none", "uncompressed":
+		writer.codec = &parquet.Uncompressed
+	default:
+		return nil, errors.New("Unsupported Parquet compression: " + options.Compression)
+	}
+	return writer, nil
+}
+
+func (w *parquetWriter) ContentType() string {
+	return "application/vnd.apache.parquet"
+}
+
+func (w *parquetWriter) Extension() string {
+	return ".parquet"
+}
+
+func (w *parquetWriter) SetColumnTypes(columns []query.ColumnInfo) {
+	w.types = columns
+}
+
+func (w *parquetWriter) WriteHeader(header []string) error {
+	w.columns = make([]parquetColumn, len(header))
+	root := make(parquetGroup, len(header))
+	for i, name := range header {
+		// 컬럼 타입이 지정되지 않은 경우 문자열로 처리
+		column := parquetColumn{name: name, index: i, info: query.ColumnInfo{Name: name, Type: query.VT_STRING}}
+		if i < len(w.types) {
+			column.info = w.types[i]
+		}
+		var node parquet.Node
+		switch column.info.Type {
+		case query.VT_INT64:
+			node = parquet.Int(64)
+		case query.VT_FLOAT64:
+			node = parquet.Leaf(parquet.DoubleType)
+		case query.VT_BOOL:
+			node = parquet.Leaf(parquet.BooleanType)
+		case query.VT_TIME:
+			node = parquet.Timestamp(parquet.Microsecond)
+		case query.VT_DECIMAL:
+			// 18자리 이하는 INT64, 그 외는 고정 길이 byte array로 unscaled 값 저장 (정밀도를 알 수 없는 경우 문자열)
+			precision, scale := int(column.info.Precision), int(column.info.Scale)
+			if precision <= 0 {
+				column.info.Type = query.VT_STRING
+				node = parquet.String()
+			} else if precision <= 18 {
+				node = parquet.Decimal(scale, precision, parquet.Int64Type)
+			} else {
+				column.typeLength = int(decimalLength(column.info.Precision))
+				node = parquet.Decimal(scale, precision, parquet.FixedLenByteArrayType(column.typeLength))
+			}
+		default:
+			node = parquet.String()
+		}
+		w.columns[i] = column
+		root[i] = &parquetField{Node: parquet.Optional(node), name: name}
+	}
+	w.writer = parquet.NewWriter(w.out,
+		parquet.NewSchema("schema", root),
+		parquet.Compression(w.codec),
+		&parquet.WriterConfig{CreatedBy: "dems-api-server"},
+	)
+	return nil
+}
+
+func (w *parquetWriter) WriteRow(row query.Row) error {
+	if len(row) != len(w.columns) {
+		return errors.New("Number of fields does not match header")
+	}
+	// 모든 값을 변환한 후 기록하여 행 단위로 기록
+	values := make(parquet.Row, len(row))
+	size := 0
+	for i, value := range row {
+		converted, err := w.columns[i].value(value)
+		if err != nil {
+			return err
+		}
+		values[i] = converted
+		size += 8
+		if kind := converted.Kind(); !converted.IsNull() && (kind == parquet.ByteArray || kind == parquet.FixedLenByteArray) {
+			size += len(converted.ByteArray())
+		}
+	}
+	if _, err := w.writer.WriteRows([]parquet.Row{values}); err != nil {
+		return err
+	}
+	w.nRows++
+	w.bufferedBytes += size
+	if w.nRows >= w.groupSize || w.bufferedBytes >= parquetMaxRowGroupBytes {
+		w.nRows, w.bufferedBytes = 0, 0
+		return w.writer.Flush()
+	}
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	if w.writer == nil {
+		return errors.New("Parquet header is not written")
+	}
+	return w.writer.Close()
+}
+
+/* [Internal function] Convert value to Parquet value of column (nil is NULL, definition level 0) */
+func (c *parquetColumn) value(value interface{}) (parquet.Value, error) {
+	if value == nil {
+		return parquet.NullValue().Level(0, 0, c.index), nil
+	}
+	var converted parquet.Value
+	switch c.info.Type {
+	case query.VT_INT64:
+		v, ok := value.(int64)
+		if !ok {
+			return converted, c.invalidValue(value)
+		}
+		converted = parquet.Int64Value(v)
+	case query.VT_FLOAT64:
+		v, ok := value.(float64)
+		if !ok {
+			return converted, c.invalidValue(value)
+		}
+		converted = parquet.DoubleValue(v)
+	case query.VT_BOOL:
+		v, ok := value.(bool)
+		if !ok {
+			return converted, c.invalidValue(value)
+		}
+		converted = parquet.BooleanValue(v)
+	case query.VT_TIME:
+		v, ok := value.(time.Time)
+		if !ok {
+			return converted, c.invalidValue(value)
+		}
+		converted = parquet.Int64Value(v.Unix()*1000000 + int64(v.Nanosecond()/1000))
+	case query.VT_DECIMAL:
+		v, ok := value.(query.Decimal)
+		if !ok {
+			return converted, c.invalidValue(value)
+		}
+		// 소수 자릿수가 scale보다 많은 값은 반올림
+		unscaled, ok := unscaledDecimal(string(v), c.info.Scale)
+		if !ok || unscaled.CmpAbs(pow10(c.info.Precision)) >= 0 {
+			return converted, c.invalidValue(value)
+		}
+		if c.typeLength == 0 {
+			converted = parquet.Int64Value(unscaled.Int64())
+		} else {
+			converted = parquet.FixedLenByteArrayValue(twosComplement(unscaled, c.typeLength))
+		}
+	default:
+		converted = parquet.ByteArrayValue([]byte(query.ValueString(value)))
+	}
+	return converted.Level(0, 1, c.index), nil
+}
+
+/* [Internal function] Error of value that cannot be written as column type */
+func (c *parquetColumn) invalidValue(value interface{}) error {
+	return fmt.Errorf("Invalid value of column %s (%T): %s", c.name, value, query.ValueString(value))
+}
+
+func (g parquetGroup) ID() int {
+	return 0
+}
+
+func (g parquetGroup) String() string {
+	return fmt.Sprintf("group(%d columns)", len(g))
+}
+
+func (g parquetGroup) Type() parquet.Type {
+	return parquet.Group{}.Type()
+}
+
+func (g parquetGroup) Optional() bool {
+	return false
+}
+
+func (g parquetGroup) Repeated() bool {
+	return false
+}
+
+func (g parquetGroup) Required() bool {
+	return true
+}
+
+func (g parquetGroup) Leaf() bool {
+	return false
+}
+
+func (g parquetGroup) Fields() []parquet.Field {
+	return g
+}
+
+func (g parquetGroup) Encoding() encoding.Encoding {
+	return nil
+}
+
+func (g parquetGroup) Compression() compress.Codec {
+	return nil
+}
+
+/* Rows are written as parquet.Row, so the Go type is only used to describe the schema */
+func (g parquetGroup) GoType() reflect.Type {
+	return reflect.TypeOf(map[string]interface{}(nil))
+}
+
+func (f *parquetField) Name() string {
+	return f.name
+}
+
+func (f *parquetField) Value(base reflect.Value) reflect.Value {
+	return base.MapIndex(reflect.ValueOf(f.name))
+}
+
+/* [Internal function] Convert decimal string to unscaled integer (value * 10^scale, rounded half away from zero) */
+func unscaledDecimal(value string, scale int64) (*big.Int, bool) {
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, false
+	}
+	rat.Mul(rat, new(big.Rat).SetInt(pow10(scale)))
+	if rat.IsInt() {
+		return rat.Num(), true
+	}
+	quotient, remainder := new(big.Int).QuoRem(rat.Num(), rat.Denom(), new(big.Int))
+	// 나머지가 절반 이상이면 0에서 먼 쪽으로 반올림
+	if remainder.Abs(remainder).Lsh(remainder, 1).Cmp(rat.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(rat.Sign())))
+	}
+	return quotient, true
+}
+
+/* [Internal function] Minimum byte length to store unscaled decimal of precision */
+func decimalLength(precision int64) int32 {
+	// 부호 비트 포함
+	return int32((pow10(precision).BitLen() + 1 + 7) / 8)
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+/* [Internal function] Big-endian two's complement of fixed length */
+func twosComplement(value *big.Int, length int) []byte {
+	encoded := make([]byte, length)
+	if value.Sign() >= 0 {
+		value.FillBytes(encoded)
+		return encoded
+	}
+	// 음수는 2^(8*length) + value
+	complement := new(big.Int).Lsh(big.NewInt(1), uint(8*length))
+	complement.Add(complement, value)
+	complement.FillBytes(encoded)
+	return encoded
+}
@@ -0,0 +1,323 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+	// Parquet reader
+	parquet "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+// Column types of round-trip test
+var parquetColumns = []query.ColumnInfo{
+	{Name: "ID", Type: query.VT_INT64},
+	{Name: "NAME", Type: query.VT_STRING},
+	{Name: "SCORE", Type: query.VT_FLOAT64},
+	{Name: "ACTIVE", Type: query.VT_BOOL},
+	{Name: "AMOUNT", Type: query.VT_DECIMAL, Precision: 10, Scale: 2},
+	{Name: "BALANCE", Type: query.VT_DECIMAL, Precision: 30, Scale: 4},
+	{Name: "CREATED", Type: query.VT_TIME},
+}
+
+/* [Internal function] Write rows as Parquet file */
+func writeParquet(t *testing.T, options ParquetOptions, rows []query.Row) []byte {
+	var buf bytes.Buffer
+	writer, err := NewParquetWriter(&buf, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := make([]string, len(parquetColumns))
+	for i, column := range parquetColumns {
+		header[i] = column.Name
+	}
+	writer.(TypedWriter).SetColumnTypes(parquetColumns)
+	if err := writer.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 15, 123456000, time.UTC)
+	rows := []query.Row{
+		{int64(1), "홍길동", 1.5, true, query.Decimal("12.34"), query.Decimal("-123456789012345678901234.5678"), created},
+		{int64(-2), "", nil, false, query.Decimal("-0.05"), query.Decimal("1"), nil},
+		{nil, nil, -2.25, nil, nil, nil, created.Add(time.Hour)},
+		{int64(9223372036854775807), "a,b", 0.0, true, query.Decimal("99999999.99"), query.Decimal("0.0001"), created},
+		{int64(5), nil, nil, nil, nil, nil, nil},
+	}
+	for _, compression := range []string{"snappy", "none"} {
+		t.Run(compression, func(t *testing.T) {
+			data := writeParquet(t, ParquetOptions{RowGroupSize: 2, Compression: compression}, rows)
+
+			// 파일 앞뒤의 magic number와 footer 길이
+			if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+				t.Fatalf("magic = %q ... %q", data[:4], data[len(data)-4:])
+			}
+			footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+			if footerLength <= 0 || footerLength > len(data)-12 {
+				t.Fatalf("footer length = %d (file %d bytes)", footerLength, len(data))
+			}
+
+			file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			metadata := file.Metadata()
+			if metadata.NumRows != int64(len(rows)) || file.NumRows() != int64(len(rows)) {
+				t.Fatalf("rows = %d", metadata.NumRows)
+			}
+			if metadata.CreatedBy != "dems-api-server" {
+				t.Errorf("created by = %q", metadata.CreatedBy)
+			}
+			checkParquetSchema(t, metadata.Schema)
+
+			// 행 그룹 크기 2 → 2, 2, 1
+			groups := file.RowGroups()
+			if len(groups) != 3 {
+				t.Fatalf("row groups = %d", len(groups))
+			}
+			codec := format.Snappy
+			if compression == "none" {
+				codec = format.Uncompressed
+			}
+			var read []parquet.Row
+			for i, group := range groups {
+				if expected := []int64{2, 2, 1}[i]; group.NumRows() != expected {
+					t.Errorf("row group %d rows = %d, want %d", i, group.NumRows(), expected)
+				}
+				for _, chunk := range metadata.RowGroups[i].Columns {
+					if chunk.MetaData.Codec != codec {
+						t.Errorf("row group %d column %v codec = %v", i, chunk.MetaData.PathInSchema, chunk.MetaData.Codec)
+					}
+				}
+				read = append(read, readParquetRows(t, group)...)
+			}
+			if len(read) != len(rows) {
+				t.Fatalf("read rows = %d", len(read))
+			}
+			for i, row := range rows {
+				checkParquetRow(t, i, row, read[i])
+			}
+		})
+	}
+}
+
+func TestParquetInvalidValue(t *testing.T) {
+	var buf bytes.Buffer
+	writer, _ := NewParquetWriter(&buf, ParquetOptions{})
+	writer.(TypedWriter).SetColumnTypes(parquetColumns[:2])
+	if err := writer.WriteHeader([]string{"ID", "NAME"}); err != nil {
+		t.Fatal(err)
+	}
+	// 타입이 맞지 않는 값은 행 전체를 기록하지 않음
+	if err := writer.WriteRow(query.Row{"x", "a"}); err == nil {
+		t.Fatal("string value of INT64 column is accepted")
+	}
+	if err := writer.WriteRow(query.Row{int64(1), "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.NumRows() != 1 {
+		t.Fatalf("rows = %d", file.NumRows())
+	}
+	// 정밀도를 넘는 decimal
+	writer, _ = NewParquetWriter(&bytes.Buffer{}, ParquetOptions{})
+	writer.(TypedWriter).SetColumnTypes(parquetColumns[4:5])
+	writer.WriteHeader([]string{"AMOUNT"})
+	if err := writer.WriteRow(query.Row{query.Decimal("123456789.00")}); err == nil {
+		t.Fatal("decimal out of precision is accepted")
+	}
+	if _, err := NewParquetWriter(&bytes.Buffer{}, ParquetOptions{Compression: "gzip"}); err == nil {
+		t.Fatal("unsupported compression is accepted")
+	}
+}
+
+func TestParquetDecimalScale(t *testing.T) {
+	// 소수 자릿수가 scale보다 많은 값은 반출을 중단하지 않고 반올림
+	columns := []query.ColumnInfo{
+		{Name: "AMOUNT", Type: query.VT_DECIMAL, Precision: 10, Scale: 2},
+		{Name: "BALANCE", Type: query.VT_DECIMAL, Precision: 30, Scale: 4},
+	}
+	values := []struct {
+		amount   query.Decimal
+		balance  query.Decimal
+		expected [2]int64
+	}{
+		{"1.005", "0.00005", [2]int64{101, 1}},
+		{"-1.005", "-0.00005", [2]int64{-101, -1}},
+		{"2.004", "-123.456749", [2]int64{200, -1234567}},
+		{"0.125e1", "1e-5", [2]int64{125, 0}},
+	}
+	var buf bytes.Buffer
+	writer, _ := NewParquetWriter(&buf, ParquetOptions{})
+	writer.(TypedWriter).SetColumnTypes(columns)
+	if err := writer.WriteHeader([]string{"AMOUNT", "BALANCE"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range values {
+		if err := writer.WriteRow(query.Row{value.amount, value.balance}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := readParquetRows(t, file.RowGroups()[0])
+	if len(rows) != len(values) {
+		t.Fatalf("rows = %d", len(rows))
+	}
+	for i, value := range values {
+		amount, balance := rows[i][0].Int64(), fromTwosComplement(rows[i][1].ByteArray())
+		if amount != value.expected[0] || balance.Int64() != value.expected[1] {
+			t.Errorf("%s, %s = %d, %s, want %v", value.amount, value.balance, amount, balance, value.expected)
+		}
+	}
+}
+
+/* [Internal function] Check physical, logical type and repetition of schema elements */
+func checkParquetSchema(t *testing.T, schema []format.SchemaElement) {
+	if len(schema) != len(parquetColumns)+1 || schema[0].NumChildren != int32(len(parquetColumns)) {
+		t.Fatalf("schema = %+v", schema)
+	}
+	expected := []struct {
+		physical  format.Type
+		length    int32
+		precision int32
+		scale     int32
+	}{
+		{format.Int64, 0, 0, 0},
+		{format.ByteArray, 0, 0, 0},
+		{format.Double, 0, 0, 0},
+		{format.Boolean, 0, 0, 0},
+		{format.Int64, 0, 10, 2},
+		{format.FixedLenByteArray, decimalLength(30), 30, 4},
+		{format.Int64, 0, 0, 0},
+	}
+	for i, element := range schema[1:] {
+		column := parquetColumns[i]
+		if element.Name != column.Name {
+			t.Errorf("column %d name = %s", i, element.Name)
+		}
+		if element.RepetitionType == nil || *element.RepetitionType != format.Optional {
+			t.Errorf("column %s is not optional", element.Name)
+		}
+		if element.Type == nil || *element.Type != expected[i].physical {
+			t.Errorf("column %s type = %v", element.Name, element.Type)
+		}
+		if expected[i].length > 0 && (element.TypeLength == nil || *element.TypeLength != expected[i].length) {
+			t.Errorf("column %s length = %v", element.Name, element.TypeLength)
+		}
+		if expected[i].precision > 0 {
+			if element.Precision == nil || *element.Precision != expected[i].precision ||
+				element.Scale == nil || *element.Scale != expected[i].scale {
+				t.Errorf("column %s decimal(%v, %v)", element.Name, element.Precision, element.Scale)
+			}
+		}
+	}
+	if logical := schema[2].LogicalType; logical == nil || logical.UTF8 == nil {
+		t.Errorf("NAME logical type = %+v", logical)
+	}
+	if logical := schema[7].LogicalType; logical == nil || logical.Timestamp == nil ||
+		logical.Timestamp.Unit.Micros == nil || !logical.Timestamp.IsAdjustedToUTC {
+		t.Errorf("CREATED logical type = %+v", logical)
+	}
+}
+
+/* [Internal function] Read all rows of row group */
+func readParquetRows(t *testing.T, group parquet.RowGroup) []parquet.Row {
+	rows := group.Rows()
+	defer rows.Close()
+	var read []parquet.Row
+	buf := make([]parquet.Row, 1)
+	for {
+		n, err := rows.ReadRows(buf)
+		if n > 0 {
+			read = append(read, buf[0].Clone())
+		}
+		if err == io.EOF {
+			return read
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+/* [Internal function] Compare written row with read values (NULL is definition level 0) */
+func checkParquetRow(t *testing.T, i int, expected query.Row, row parquet.Row) {
+	if len(row) != len(expected) {
+		t.Fatalf("row %d has %d values", i, len(row))
+	}
+	for c, value := range row {
+		name := parquetColumns[c].Name
+		if expected[c] == nil {
+			if !value.IsNull() || value.DefinitionLevel() != 0 {
+				t.Errorf("row %d %s = %v, want NULL", i, name, value)
+			}
+			continue
+		}
+		if value.IsNull() || value.DefinitionLevel() != 1 {
+			t.Errorf("row %d %s is NULL (definition level %d)", i, name, value.DefinitionLevel())
+			continue
+		}
+		var got interface{}
+		switch parquetColumns[c].Type {
+		case query.VT_INT64:
+			got = value.Int64()
+		case query.VT_STRING:
+			got = string(value.ByteArray())
+		case query.VT_FLOAT64:
+			got = value.Double()
+		case query.VT_BOOL:
+			got = value.Boolean()
+		case query.VT_TIME:
+			got = time.UnixMicro(value.Int64()).UTC()
+		case query.VT_DECIMAL:
+			unscaled := big.NewInt(value.Int64())
+			if parquetColumns[c].Precision > 18 {
+				unscaled = fromTwosComplement(value.ByteArray())
+			}
+			expectedUnscaled, _ := unscaledDecimal(string(expected[c].(query.Decimal)), parquetColumns[c].Scale)
+			if unscaled.Cmp(expectedUnscaled) != 0 {
+				t.Errorf("row %d %s = %s, want %s", i, name, unscaled, expected[c])
+			}
+			continue
+		}
+		if got != expected[c] {
+			t.Errorf("row %d %s = %v, want %v", i, name, got, expected[c])
+		}
+	}
+}
+
+/* [Internal function] Big-endian two's complement to integer */
+func fromTwosComplement(b []byte) *big.Int {
+	value := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return value
+}
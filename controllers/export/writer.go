@@ -0,0 +1,131 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	// Custom package
+	"dems-api-server/controllers/query"
+	"dems-api-server/controllers/storage"
+)
+
+const (
+	// Export file format
+	FORMAT_CSV     = "csv"
+	FORMAT_JSONL   = "jsonl"
+	FORMAT_JSON    = "json"
+	FORMAT_PARQUET = "parquet"
+	FORMAT_XLSX    = "xlsx"
+)
+
+// Media type of each format (used for Accept header negotiation)
+var formatMediaTypes = map[string]string{
+	"text/csv":                       FORMAT_CSV,
+	"application/x-ndjson":           FORMAT_JSONL,
+	"application/jsonl":              FORMAT_JSONL,
+	"application/json":               FORMAT_JSON,
+	"application/vnd.apache.parquet": FORMAT_PARQUET,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FORMAT_XLSX,
+}
+
+// Export file format interface
+type Writer interface {
+	// Content type and file extension of the output
+	ContentType() string
+	Extension() string
+	// Write header (column names) and anonymized rows (NULL value is nil)
+	WriteHeader(header []string) error
+	WriteRow(row query.Row) error
+	// Flush buffered data (end of stream)
+	Close() error
+}
+
+// Writer of typed format (source column types are set before header is written)
+type TypedWriter interface {
+	Writer
+	SetColumnTypes(columns []query.ColumnInfo)
+}
+
+// Export options (query.json > export)
+type Options struct {
+	// Default format when not requested (csv)
+	Format  string         `json:"format"`
+	CSV     CSVOptions     `json:"csv"`
+	Parquet ParquetOptions `json:"parquet"`
+	// Encrypt exported file to recipients (not encrypted if not declared)
+	Encryption EncryptionOptions `json:"encryption"`
+}
+
+/* [Function] Get export options of request (definition from the database, recipient key files from the request directory) */
+func LoadOptions(requestID string) (*Options, error) {
+	options := new(Options)
+	// 현재 작업 경로 추출
+	workspace, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	// 반출 요청 정의 읽기
+	stored, err := storage.LoadDefinition(requestID)
+	if err != nil {
+		return nil, err
+	}
+	definition := struct {
+		Export *Options `json:"export"`
+	}{Export: options}
+	if err := json.Unmarshal(stored.Query, &definition); err != nil {
+		return nil, err
+	}
+	// 파일로 선언된 수신자 공개키 읽기 (공개키 파일은 데이터베이스로 가져오지 않으므로 요청 디렉토리에서 읽음)
+	if err := options.Encryption.loadRecipients(path.Join(workspace, storage.DEFAULT_DEFINITION_DIR, requestID)); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+/* [Function] Select export format (format parameter > Accept header > default format of request) */
+func NegotiateFormat(format string, accept string, options *Options) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	// Accept 헤더에 나열된 순서대로 지원하는 형식 선택 (q 값은 고려하지 않음)
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		if format, ok := formatMediaTypes[mediaType]; ok {
+			return format
+		}
+	}
+	if options.Format != "" {
+		return strings.ToLower(options.Format)
+	}
+	return FORMAT_CSV
+}
+
+/* [Function] Create writer of the export format */
+func NewWriter(format string, w io.Writer, options *Options) (Writer, error) {
+	switch format {
+	case FORMAT_CSV:
+		return NewCSVWriter(w, options.CSV)
+	case FORMAT_JSONL:
+		return NewJSONLinesWriter(w), nil
+	case FORMAT_JSON:
+		return NewJSONArrayWriter(w), nil
+	case FORMAT_PARQUET:
+		return NewParquetWriter(w, options.Parquet)
+	case FORMAT_XLSX:
+		return NewXLSXWriter(w), nil
+	default:
+		return nil, errors.New("Unsupported export format: " + format)
+	}
+}
+
+/* [Function] File name of the export (e.g. exportData.csv.gz.age) */
+func FileName(writer Writer, stream *Compressor, encryptor *Encryptor) string {
+	return "exportData" + writer.Extension() + stream.Extension() + encryptor.Extension()
+}
@@ -0,0 +1,348 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+const (
+	// Excel limits (rows per sheet, characters per cell)
+	xlsxMaxRows      = 1048576
+	xlsxMaxCellChars = 32767
+	// Numbers with more digits lose precision in Excel (written as text)
+	xlsxMaxDigits = 15
+	// Cell style index (styles.xml)
+	xlsxStyleDate   = 1
+	xlsxStyleHeader = 2
+	// Sheet names
+	xlsxDataSheet     = "data"
+	xlsxMetadataSheet = "metadata"
+)
+
+// Export metadata (written by formats that support it)
+type Metadata struct {
+	RequestID  string
+	ExportedAt time.Time
+	Columns    []ColumnMetadata
+}
+
+// Anonymization method applied to column
+type ColumnMetadata struct {
+	Name        string
+	Method      string
+	Description string
+}
+
+// Writer that writes export metadata
+type MetadataWriter interface {
+	Writer
+	SetMetadata(metadata Metadata)
+}
+
+// XLSX writer (data sheets are streamed into zip entries, shared strings are not used)
+type xlsxWriter struct {
+	archive  *zip.Writer
+	sheet    *bufio.Writer
+	metadata Metadata
+	header   []string
+	// Column reference (A, B, ..., AA)
+	refs []string
+	// Written sheet names and rows of current sheet
+	sheets    []string
+	sheetRows int
+	nRows     uint64
+}
+
+/* [Function] Create XLSX writer */
+func NewXLSXWriter(w io.Writer) Writer {
+	return &xlsxWriter{archive: zip.NewWriter(w)}
+}
+
+func (w *xlsxWriter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (w *xlsxWriter) Extension() string {
+	return ".xlsx"
+}
+
+func (w *xlsxWriter) SetMetadata(metadata Metadata) {
+	w.metadata = metadata
+}
+
+func (w *xlsxWriter) WriteHeader(header []string) error {
+	w.header = header
+	w.refs = make([]string, len(header))
+	for i := range header {
+		w.refs[i] = columnRef(i)
+	}
+	return w.beginSheet()
+}
+
+func (w *xlsxWriter) WriteRow(row query.Row) error {
+	// 시트 당 최대 행 수를 넘는 경우 다음 시트에 이어서 작성
+	if w.sheetRows >= xlsxMaxRows {
+		if err := w.endSheet(); err != nil {
+			return err
+		}
+		if err := w.beginSheet(); err != nil {
+			return err
+		}
+	}
+	w.sheetRows++
+	w.sheet.WriteString(`<row r="` + strconv.Itoa(w.sheetRows) + `">`)
+	for i, value := range row {
+		if i < len(w.refs) {
+			writeCell(w.sheet, w.refs[i]+strconv.Itoa(w.sheetRows), value)
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	w.nRows++
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	// 메타데이터 시트 (데이터 수는 작성이 끝난 후 확인 가능)
+	if err := w.writeMetadataSheet(); err != nil {
+		return err
+	}
+	// Workbook (sheet list, styles, content types)
+	if err := w.writeWorkbook(); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
+
+/* [Internal function] Begin data sheet and write header row */
+func (w *xlsxWriter) beginSheet() error {
+	name := xlsxDataSheet
+	if len(w.sheets) > 0 {
+		name += "_" + strconv.Itoa(len(w.sheets)+1)
+	}
+	w.sheets = append(w.sheets, name)
+	entry, err := w.archive.Create("xl/worksheets/sheet" + strconv.Itoa(len(w.sheets)) + ".xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(entry)
+	w.sheet.WriteString(xml.Header)
+	w.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	// 헤더 행 고정
+	w.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	w.sheet.WriteString("<sheetData>")
+	w.sheetRows = 1
+	w.sheet.WriteString(`<row r="1">`)
+	for i, name := range w.header {
+		writeStringCell(w.sheet, w.refs[i]+"1", name, xlsxStyleHeader)
+	}
+	_, err = w.sheet.WriteString("</row>")
+	return err
+}
+
+/* [Internal function] End current data sheet */
+func (w *xlsxWriter) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	w.sheet.WriteString("</sheetData></worksheet>")
+	err := w.sheet.Flush()
+	w.sheet = nil
+	return err
+}
+
+/* [Internal function] Write metadata sheet (request ID, export time, row count, anonymization method per column) */
+func (w *xlsxWriter) writeMetadataSheet() error {
+	entry, err := w.archive.Create("xl/worksheets/sheet" + strconv.Itoa(len(w.sheets)+1) + ".xml")
+	if err != nil {
+		return err
+	}
+	sheet := bufio.NewWriter(entry)
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	rows := [][]interface{}{
+		{"Request ID", w.metadata.RequestID},
+		{"Exported at", w.metadata.ExportedAt.Format(time.RFC3339)},
+		{"Rows", int64(w.nRows)},
+		{},
+		{"Column", "Method", "Description"},
+	}
+	for _, column := range w.metadata.Columns {
+		rows = append(rows, []interface{}{column.Name, column.Method, column.Description})
+	}
+	for i, row := range rows {
+		r := strconv.Itoa(i + 1)
+		sheet.WriteString(`<row r="` + r + `">`)
+		for j, value := range row {
+			ref := columnRef(j) + r
+			// 항목 이름과 컬럼 목록의 제목은 굵게 표시
+			if (i < 3 && j == 0) || i == 4 {
+				writeStringCell(sheet, ref, query.ValueString(value), xlsxStyleHeader)
+			} else {
+				writeCell(sheet, ref, value)
+			}
+		}
+		sheet.WriteString("</row>")
+	}
+	sheet.WriteString("</sheetData></worksheet>")
+	return sheet.Flush()
+}
+
+/* [Internal function] Write workbook parts (sheet list, relationships, styles, content types) */
+func (w *xlsxWriter) writeWorkbook() error {
+	sheets := append(append([]string{}, w.sheets...), xlsxMetadataSheet)
+	var workbook, relations, overrides strings.Builder
+	for i, name := range sheets {
+		id := strconv.Itoa(i + 1)
+		workbook.WriteString(`<sheet name="` + name + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		relations.WriteString(`<Relationship Id="rId` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + id + `.xml"/>`)
+		overrides.WriteString(`<Override PartName="/xl/worksheets/sheet` + id + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+	}
+	stylesID := strconv.Itoa(len(sheets) + 1)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + workbook.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + relations.String() + `<Relationship Id="rId` + stylesID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` + overrides.String() + `</Types>`},
+	}
+	for _, part := range parts {
+		entry, err := w.archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cell styles (0: default, 1: date time, 2: bold header)
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+/* [Internal function] Write cell by value type (NULL is empty cell, text keeps leading zeros) */
+func writeCell(out *bufio.Writer, ref string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case int64:
+		text := strconv.FormatInt(v, 10)
+		if len(strings.TrimPrefix(text, "-")) > xlsxMaxDigits {
+			writeStringCell(out, ref, text, 0)
+			return
+		}
+		writeNumberCell(out, ref, text, 0)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			writeStringCell(out, ref, query.ValueString(v), 0)
+			return
+		}
+		writeNumberCell(out, ref, strconv.FormatFloat(v, 'g', -1, 64), 0)
+	case query.Decimal:
+		// 유효 숫자가 많은 decimal은 정밀도 유지를 위해 문자열로 작성
+		rat, ok := new(big.Rat).SetString(string(v))
+		if !ok || significantDigits(string(v)) > xlsxMaxDigits {
+			writeStringCell(out, ref, string(v), 0)
+			return
+		}
+		number, _ := rat.Float64()
+		writeNumberCell(out, ref, strconv.FormatFloat(number, 'g', -1, 64), 0)
+	case bool:
+		flag := "0"
+		if v {
+			flag = "1"
+		}
+		out.WriteString(`<c r="` + ref + `" t="b"><v>` + flag + `</v></c>`)
+	case time.Time:
+		serial, ok := excelSerial(v)
+		if !ok {
+			writeStringCell(out, ref, query.ValueString(v), 0)
+			return
+		}
+		writeNumberCell(out, ref, strconv.FormatFloat(serial, 'f', -1, 64), xlsxStyleDate)
+	default:
+		writeStringCell(out, ref, query.ValueString(v), 0)
+	}
+}
+
+func writeNumberCell(out *bufio.Writer, ref string, number string, style int) {
+	out.WriteString(`<c r="` + ref + `"`)
+	if style > 0 {
+		out.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	out.WriteString(`><v>` + number + `</v></c>`)
+}
+
+/* [Internal function] Write inline string cell (truncated to Excel cell limit) */
+func writeStringCell(out *bufio.Writer, ref string, text string, style int) {
+	if utf8.RuneCountInString(text) > xlsxMaxCellChars {
+		text = string([]rune(text)[:xlsxMaxCellChars])
+	}
+	out.WriteString(`<c r="` + ref + `" t="inlineStr"`)
+	if style > 0 {
+		out.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	out.WriteString(`><is><t xml:space="preserve">`)
+	// XML에서 사용할 수 없는 문자는 대체 문자로 변환
+	xml.EscapeText(out, []byte(text))
+	out.WriteString(`</t></is></c>`)
+}
+
+/* [Internal function] Column reference of index (0: A, 26: AA) */
+func columnRef(index int) string {
+	ref := ""
+	for index >= 0 {
+		ref = string(rune('A'+index%26)) + ref
+		index = index/26 - 1
+	}
+	return ref
+}
+
+/* [Internal function] Number of significant digits of decimal text */
+func significantDigits(text string) int {
+	digits := strings.Trim(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text), "0")
+	return len(digits)
+}
+
+/* [Internal function] Excel date serial (days since 1899-12-30, wall clock time) */
+func excelSerial(t time.Time) (float64, bool) {
+	// 1900-03-01 이전은 Excel 날짜 체계(1900 윤년 오류)로 표현할 수 없으므로 문자열로 작성
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if wall.Year() < 1900 || (wall.Year() == 1900 && wall.Month() < time.March) || wall.Year() > 9999 {
+		return 0, false
+	}
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	seconds := wall.Unix() - epoch.Unix()
+	return (float64(seconds) + float64(wall.Nanosecond()/1000000)/1000) / 86400, true
+}
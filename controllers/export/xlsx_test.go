@@ -0,0 +1,157 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	// Excel reader
+	"github.com/xuri/excelize/v2"
+	// Custom package
+	"dems-api-server/controllers/query"
+)
+
+/* [Internal function] Write rows with XLSX writer and open output with excelize */
+func writeXLSX(t *testing.T, metadata Metadata, header []string, rows func(write func(query.Row))) *excelize.File {
+	var buf bytes.Buffer
+	writer := NewXLSXWriter(&buf)
+	writer.(MetadataWriter).SetMetadata(metadata)
+	if err := writer.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	rows(func(row query.Row) {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+/* [Internal function] Check cell value and type */
+func checkCell(t *testing.T, file *excelize.File, sheet, ref, value string, cellType excelize.CellType) {
+	t.Helper()
+	got, err := file.GetCellValue(sheet, ref, excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotType, _ := file.GetCellType(sheet, ref)
+	if got != value || gotType != cellType {
+		t.Errorf("%s!%s = %q (type %d), want %q (type %d)", sheet, ref, got, gotType, value, cellType)
+	}
+}
+
+func TestXLSXCells(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	file := writeXLSX(t, Metadata{}, []string{"ID", "CODE", "AMOUNT", "PRECISE", "ACTIVE", "CREATED", "MEMO"}, func(write func(query.Row)) {
+		write(query.Row{int64(7), "007", query.Decimal("-12.50"), query.Decimal("12345678901234567890.1"), true, created, "<a & b>"})
+		write(query.Row{int64(1234567890123456789), nil, 1.5, query.Decimal("0.1"), false, time.Date(1800, 1, 1, 0, 0, 0, 0, time.UTC), ""})
+	})
+	if sheets := file.GetSheetList(); !reflect.DeepEqual(sheets, []string{xlsxDataSheet, xlsxMetadataSheet}) {
+		t.Fatalf("sheets = %q", sheets)
+	}
+	// 헤더 행 고정
+	if panes, err := file.GetPanes(xlsxDataSheet); err != nil || !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("panes = %+v (%v)", panes, err)
+	}
+	checkCell(t, file, xlsxDataSheet, "A1", "ID", excelize.CellTypeInlineString)
+	checkCell(t, file, xlsxDataSheet, "A2", "7", excelize.CellTypeUnset)
+	// 앞자리 0은 문자열로 유지
+	checkCell(t, file, xlsxDataSheet, "B2", "007", excelize.CellTypeInlineString)
+	checkCell(t, file, xlsxDataSheet, "C2", "-12.5", excelize.CellTypeUnset)
+	// 15자리를 넘는 숫자는 문자열
+	checkCell(t, file, xlsxDataSheet, "D2", "12345678901234567890.1", excelize.CellTypeInlineString)
+	checkCell(t, file, xlsxDataSheet, "A3", "1234567890123456789", excelize.CellTypeInlineString)
+	checkCell(t, file, xlsxDataSheet, "E2", "1", excelize.CellTypeBool)
+	checkCell(t, file, xlsxDataSheet, "E3", "0", excelize.CellTypeBool)
+	checkCell(t, file, xlsxDataSheet, "G2", "<a & b>", excelize.CellTypeInlineString)
+	// NULL은 빈 셀, 빈 문자열은 문자열 셀
+	checkCell(t, file, xlsxDataSheet, "B3", "", excelize.CellTypeUnset)
+	checkCell(t, file, xlsxDataSheet, "G3", "", excelize.CellTypeInlineString)
+	// 날짜는 날짜 서식의 serial, Excel 날짜 범위 밖은 문자열
+	checkCell(t, file, xlsxDataSheet, "F2", "45352.5", excelize.CellTypeUnset)
+	if formatted, _ := file.GetCellValue(xlsxDataSheet, "F2"); formatted != "2024-03-01 12:00:00" {
+		t.Errorf("formatted date = %q", formatted)
+	}
+	checkCell(t, file, xlsxDataSheet, "F3", "1800-01-01T00:00:00Z", excelize.CellTypeInlineString)
+}
+
+func TestXLSXMetadata(t *testing.T) {
+	metadata := Metadata{
+		RequestID:  "req-1",
+		ExportedAt: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		Columns: []ColumnMetadata{
+			{Name: "NAME", Method: "encryption", Description: "AES"},
+			{Name: "AGE", Method: "rounding"},
+		},
+	}
+	file := writeXLSX(t, metadata, []string{"NAME", "AGE"}, func(write func(query.Row)) {
+		for i := 0; i < 3; i++ {
+			write(query.Row{"name", int64(i)})
+		}
+	})
+	rows, err := file.GetRows(xlsxMetadataSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"Request ID", "req-1"},
+		{"Exported at", "2024-03-01T09:00:00Z"},
+		{"Rows", "3"},
+		nil,
+		{"Column", "Method", "Description"},
+		{"NAME", "encryption", "AES"},
+		{"AGE", "rounding"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("metadata = %q\nwant %q", rows, expected)
+	}
+}
+
+func TestXLSXRollover(t *testing.T) {
+	// 첫 시트는 헤더와 최대 행 수 - 1개의 데이터, 나머지는 다음 시트에 헤더와 함께 작성
+	total := xlsxMaxRows - 1 + 2
+	file := writeXLSX(t, Metadata{}, []string{"N"}, func(write func(query.Row)) {
+		row := make(query.Row, 1)
+		for i := 1; i <= total; i++ {
+			row[0] = int64(i)
+			write(row)
+		}
+	})
+	if sheets := file.GetSheetList(); !reflect.DeepEqual(sheets, []string{xlsxDataSheet, xlsxDataSheet + "_2", xlsxMetadataSheet}) {
+		t.Fatalf("sheets = %q", sheets)
+	}
+	rows, err := file.Rows(xlsxDataSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	rows.Close()
+	if count != xlsxMaxRows {
+		t.Errorf("rows of first sheet = %d", count)
+	}
+	last := strconv.Itoa(xlsxMaxRows)
+	checkCell(t, file, xlsxDataSheet, "A"+last, strconv.Itoa(xlsxMaxRows-1), excelize.CellTypeUnset)
+	second, err := file.GetRows(xlsxDataSheet + "_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]string{{"N"}, {strconv.Itoa(total - 1)}, {strconv.Itoa(total)}}; !reflect.DeepEqual(second, expected) {
+		t.Errorf("second sheet = %q", second)
+	}
+	if value, _ := file.GetCellValue(xlsxMetadataSheet, "B3"); value != strconv.Itoa(total) {
+		t.Errorf("metadata rows = %s", value)
+	}
+}
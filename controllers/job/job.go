@@ -0,0 +1,473 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/allowlist"
+	anony "dems-api-server/controllers/anonymous"
+	"dems-api-server/controllers/apikey"
+	"dems-api-server/controllers/artifact"
+	"dems-api-server/controllers/oidc"
+)
+
+const (
+	// Job state
+	JS_QUEUED  = "queued"
+	JS_RUNNING = "running"
+	JS_DONE    = "done"
+	JS_FAILED  = "failed"
+)
+
+// Interval of removing expired jobs
+const pruneInterval = 10 * time.Minute
+
+// Job ID format (32 hex characters)
+var jobIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Background export job (persisted as <jobID>.json, result file is the artifact of the export)
+type Job struct {
+	ID        string `json:"id"`
+	RequestID string `json:"requestId"`
+	State     string `json:"state"`
+	// API key or user that created the job (recorded in the export history and the audit log)
+	KeyID   string `json:"keyId,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	// Client address of the job request (recorded in the audit log of the export)
+	ClientIP string `json:"clientIp,omitempty"`
+	// Verified client certificate of the job request (access policy is checked again when the job runs)
+	CertSubject    string `json:"certSubject,omitempty"`
+	CertCommonName string `json:"certCommonName,omitempty"`
+	// Rows processed (saved rows while running)
+	Rows  uint64 `json:"rows"`
+	Error string `json:"error,omitempty"`
+	// Export parameters (query parameters and Accept header of the job request)
+	Params url.Values `json:"params,omitempty"`
+	Accept string     `json:"accept,omitempty"`
+	// Time of each state
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Result file (done, kept until the artifact expires)
+	ExportID        string     `json:"exportId,omitempty"`
+	FileName        string     `json:"fileName,omitempty"`
+	ContentType     string     `json:"contentType,omitempty"`
+	ContentEncoding string     `json:"contentEncoding,omitempty"`
+	Size            uint64     `json:"size,omitempty"`
+	SHA256          string     `json:"sha256,omitempty"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	// Saved rows of running job (updated by the export pipeline)
+	progress uint64
+}
+
+// Response writer of job (the export is saved by the artifact store, only error message is kept)
+type jobResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+var (
+	jobs    = make(map[string]*Job)
+	jobsMu  sync.Mutex
+	queue   chan string
+	startMu sync.Mutex
+	// Echo instance and export handler (jobs run the same handler as the synchronous export)
+	server  *echo.Echo
+	handler echo.HandlerFunc
+)
+
+/* [Function] Start job workers (jobs not finished before restart are queued again) */
+func Start(e *echo.Echo, exportHandler echo.HandlerFunc) error {
+	startMu.Lock()
+	defer startMu.Unlock()
+	if queue != nil {
+		return nil
+	}
+	config := configs.Get().Job
+	server = e
+	handler = exportHandler
+	queue = make(chan string, config.QueueSize)
+	dir, err := jobDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// 저장된 작업 불러오기
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var pending []*Job
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		job := new(Job)
+		if err := json.Unmarshal(content, job); err != nil || !jobIDPattern.MatchString(job.ID) {
+			log.Print("Invalid job file: " + file)
+			continue
+		}
+		// 완료되지 않은 작업은 처음부터 다시 실행
+		if job.State == JS_QUEUED || job.State == JS_RUNNING {
+			job.State = JS_QUEUED
+			job.Rows = 0
+			job.StartedAt = nil
+			if err := save(job); err != nil {
+				return errors.New("Failed to save job " + job.ID + ": " + err.Error())
+			}
+			pending = append(pending, job)
+		}
+		jobs[job.ID] = job
+	}
+	// 보관 기간이 지난 작업은 불러온 후 바로 삭제
+	prune(time.Now())
+	go func() {
+		for {
+			time.Sleep(pruneInterval)
+			prune(time.Now())
+		}
+	}()
+	// 생성 순서대로 실행
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go work()
+	}
+	go func() {
+		for _, job := range pending {
+			queue <- job.ID
+		}
+	}()
+	return nil
+}
+
+/* [Function] Create job of the request and queue it (request, requester and export parameters are copied) */
+func Create(request *Job) (*Job, error) {
+	if queue == nil {
+		return nil, errors.New("Job workers are not started")
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	job := &Job{
+		ID:             hex.EncodeToString(id),
+		RequestID:      request.RequestID,
+		State:          JS_QUEUED,
+		KeyID:          request.KeyID,
+		Subject:        request.Subject,
+		ClientIP:       request.ClientIP,
+		CertSubject:    request.CertSubject,
+		CertCommonName: request.CertCommonName,
+		Params:         request.Params,
+		Accept:         request.Accept,
+		CreatedAt:      time.Now(),
+	}
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	// 재시작 후에도 실행되도록 저장한 후 등록 (worker는 등록이 끝난 후 작업을 조회)
+	if err := save(job); err != nil {
+		return nil, errors.New("Failed to save job: " + err.Error())
+	}
+	select {
+	case queue <- job.ID:
+	default:
+		remove(job.ID)
+		return nil, &echo.HTTPError{Code: http.StatusServiceUnavailable, Message: "Job queue is full"}
+	}
+	jobs[job.ID] = job
+	return snapshot(job), nil
+}
+
+/* [Function] Get job state (nil if not exists) */
+func Get(jobID string) *Job {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	job, ok := jobs[jobID]
+	if !ok {
+		return nil
+	}
+	return snapshot(job)
+}
+
+/* [Function] Queued and running jobs of request */
+func Active(requestID string) []*Job {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	var active []*Job
+	for _, job := range jobs {
+		if job.RequestID == requestID && (job.State == JS_QUEUED || job.State == JS_RUNNING) {
+			active = append(active, snapshot(job))
+		}
+	}
+	return active
+}
+
+/* [Internal function] Job worker (runs queued jobs one by one) */
+func work() {
+	for jobID := range queue {
+		jobsMu.Lock()
+		job, ok := jobs[jobID]
+		if !ok || job.State != JS_QUEUED {
+			jobsMu.Unlock()
+			continue
+		}
+		now := time.Now()
+		job.State = JS_RUNNING
+		job.StartedAt = &now
+		err := save(job)
+		jobsMu.Unlock()
+
+		var result *Job
+		if err != nil {
+			// 실행 상태를 저장할 수 없으면 반출하지 않음
+			err = errors.New("Failed to save job state: " + err.Error())
+		} else {
+			result, err = run(job)
+		}
+
+		jobsMu.Lock()
+		finish(job, result, err)
+		jobsMu.Unlock()
+	}
+}
+
+/* [Internal function] Set result of finished job and save it (error of saving is kept in the job state) */
+func finish(job *Job, result *Job, err error) {
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		job.State = JS_FAILED
+		job.Error = err.Error()
+		job.Rows = atomic.LoadUint64(&job.progress)
+	} else {
+		job.State = JS_DONE
+		job.Rows = result.Rows
+		job.ExportID = result.ExportID
+		job.FileName = result.FileName
+		job.ContentType = result.ContentType
+		job.ContentEncoding = result.ContentEncoding
+		job.Size = result.Size
+		job.SHA256 = result.SHA256
+		job.ExpiresAt = result.ExpiresAt
+	}
+	if err := save(job); err != nil {
+		// 저장되지 않은 작업은 재시작 후 다시 실행되므로 상태 조회 시 알 수 있도록 기록
+		log.Print("Failed to save job " + job.ID + ": " + err.Error())
+		job.Error = "Failed to save job state: " + err.Error()
+	}
+}
+
+/* [Internal function] Run export of job (result is saved by the artifact store) */
+func run(job *Job) (*Job, error) {
+	// 대기 중에 폐기되거나 만료된 API 키와 변경된 접근 정책은 실행 전에 다시 확인
+	if err := authorize(job); err != nil {
+		return nil, err
+	}
+	res := &jobResponse{header: make(http.Header)}
+	// 동기 반출과 같은 handler로 처리 (요청 정의, 반출 형식, 비식별화, 매니페스트, 반출 파일 보관)
+	req, err := http.NewRequest(http.MethodGet, "/request/"+url.PathEscape(job.RequestID)+"?"+job.Params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(anony.WithProgress(context.Background(), &job.progress))
+	if job.ClientIP != "" {
+		req.RemoteAddr = net.JoinHostPort(job.ClientIP, "0")
+	}
+	if job.Accept != "" {
+		req.Header.Set(echo.HeaderAccept, job.Accept)
+	}
+	ctx := server.NewContext(req, res)
+	ctx.SetParamNames("requestID")
+	ctx.SetParamValues(job.RequestID)
+	if job.KeyID != "" {
+		ctx.Set(apikey.CONTEXT_KEY, &apikey.Key{ID: job.KeyID, RequestID: job.RequestID})
+	} else if job.Subject != "" {
+		ctx.Set(oidc.CONTEXT_KEY, &oidc.Identity{Subject: job.Subject})
+	}
+	if err := handler(ctx); err != nil {
+		return nil, err
+	}
+	if err := res.result(); err != nil {
+		return nil, err
+	}
+	stored, _, err := artifact.Get(job.RequestID, res.header.Get("X-Export-ID"))
+	if err != nil {
+		return nil, errors.New("Failed to save result: " + err.Error())
+	}
+	result := &Job{
+		ExportID:        stored.ExportID,
+		FileName:        stored.FileName,
+		ContentType:     stored.ContentType,
+		ContentEncoding: stored.ContentEncoding,
+		Size:            stored.Size,
+		SHA256:          stored.SHA256,
+		ExpiresAt:       &stored.ExpiresAt,
+	}
+	result.Rows, _ = strconv.ParseUint(res.header.Get("X-Export-Rows"), 10, 64)
+	return result, nil
+}
+
+/* [Internal function] Check API key and access policy of job again (the job runs without the original request) */
+func authorize(job *Job) error {
+	if job.KeyID != "" {
+		key, err := apikey.Get(job.KeyID)
+		if err == apikey.ErrNotFound || (err == nil && (!key.Active(time.Now()) || key.RequestID != job.RequestID)) {
+			return errors.New("API key of the job is revoked or expired")
+		} else if err != nil {
+			return err
+		}
+	}
+	policy, err := allowlist.LoadPolicy(job.RequestID)
+	if err != nil {
+		return err
+	}
+	return policy.CheckClient(job.ClientIP, job.CertSubject, job.CertCommonName)
+}
+
+func (r *jobResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *jobResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *jobResponse) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	// 반출 데이터는 보관하지 않음 (오류 메시지만 보관)
+	if r.status != http.StatusOK {
+		r.body.Write(p)
+	}
+	return len(p), nil
+}
+
+/* [Internal function] Result of export handler (error message of failed export) */
+func (r *jobResponse) result() error {
+	if r.status != http.StatusOK {
+		// 스트림 시작 전 오류는 JSON 메시지로 작성됨
+		message := struct {
+			Message []string `json:"message"`
+		}{}
+		if json.Unmarshal(r.body.Bytes(), &message) == nil && len(message.Message) > 0 {
+			return errors.New(strings.Join(message.Message, ", "))
+		}
+		return errors.New("Export failed with status " + strconv.Itoa(r.status))
+	}
+	if status := r.header.Get("X-Export-Status"); status != "success" {
+		return errors.New("Export " + status)
+	}
+	return nil
+}
+
+/* [Internal function] Copy of job state (rows of running job is the current progress) */
+func snapshot(job *Job) *Job {
+	copied := *job
+	if job.State == JS_RUNNING {
+		copied.Rows = atomic.LoadUint64(&job.progress)
+	}
+	copied.progress = 0
+	return &copied
+}
+
+/* [Internal function] Remove finished jobs whose retention period has passed (same as artifacts) */
+func prune(now time.Time) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	for id, job := range jobs {
+		if !expired(job, now) {
+			continue
+		}
+		delete(jobs, id)
+		remove(id)
+	}
+}
+
+/* [Internal function] Check if finished job is expired (result file expiration or finished time + retention) */
+func expired(job *Job, now time.Time) bool {
+	if job.State != JS_DONE && job.State != JS_FAILED {
+		return false
+	}
+	if job.State == JS_DONE && job.ExpiresAt != nil {
+		return now.After(*job.ExpiresAt)
+	}
+	return job.FinishedAt != nil && now.After(job.FinishedAt.Add(artifact.Retention()))
+}
+
+/* [Internal function] Save job state (written to temporary file, then renamed) */
+func save(job *Job) error {
+	dir, err := jobDir()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	json.Indent(&buf, content, "", "  ")
+	file := filepath.Join(dir, job.ID+".json")
+	if err := ioutil.WriteFile(file+".tmp", buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+/* [Internal function] Remove saved job file */
+func remove(jobID string) {
+	dir, err := jobDir()
+	if err != nil {
+		return
+	}
+	if err := os.Remove(filepath.Join(dir, jobID+".json")); err != nil && !os.IsNotExist(err) {
+		log.Print(err)
+	}
+}
+
+/* [Internal function] Directory of jobs (relative to the workspace) */
+func jobDir() (string, error) {
+	dir := configs.Get().Job.Dir
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	workspace, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(workspace, dir), nil
+}
@@ -0,0 +1,170 @@
+package job
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/apikey"
+	"dems-api-server/controllers/storage"
+)
+
+func TestMain(m *testing.M) {
+	// 작업 저장 경로는 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-job")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := jobDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	hours := func(n int) *time.Time {
+		at := now.Add(time.Duration(n) * time.Hour)
+		return &at
+	}
+	cases := []struct {
+		job    *Job
+		pruned bool
+	}{
+		// 결과 파일이 만료된 작업
+		{&Job{State: JS_DONE, FinishedAt: hours(-30), ExpiresAt: hours(-6)}, true},
+		{&Job{State: JS_DONE, FinishedAt: hours(-30), ExpiresAt: hours(1)}, false},
+		// 실패한 작업은 완료 시간 + 보관 기간 (기본값 24시간)
+		{&Job{State: JS_FAILED, FinishedAt: hours(-25)}, true},
+		{&Job{State: JS_FAILED, FinishedAt: hours(-23)}, false},
+		// 완료되지 않은 작업은 유지
+		{&Job{State: JS_QUEUED}, false},
+		{&Job{State: JS_RUNNING, StartedAt: hours(-48)}, false},
+	}
+	for i, c := range cases {
+		c.job.ID = string(rune('a'+i)) + "0000000000000000000000000000000"
+		jobs[c.job.ID] = c.job
+		if err := save(c.job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prune(now)
+	for _, c := range cases {
+		_, kept := jobs[c.job.ID]
+		_, err := os.Stat(filepath.Join(dir, c.job.ID+".json"))
+		if kept == c.pruned || os.IsNotExist(err) != c.pruned {
+			t.Errorf("%s job (finished %v, expires %v): kept = %v, file error = %v", c.job.State, c.job.FinishedAt, c.job.ExpiresAt, kept, err)
+		}
+		if Get(c.job.ID) == nil != c.pruned {
+			t.Errorf("%s job: Get = %v", c.job.State, Get(c.job.ID))
+		}
+	}
+}
+
+func TestCreateWithoutSavedState(t *testing.T) {
+	dir, err := jobDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(started chan string) { queue = started }(queue)
+	queue = make(chan string, 1)
+
+	// 저장할 수 없는 작업은 등록하지 않음
+	os.RemoveAll(dir)
+	if err := ioutil.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if created, err := Create(&Job{RequestID: "job-save"}); err == nil {
+		t.Fatalf("job %s is created without saved state", created.ID)
+	}
+	if len(queue) != 0 {
+		t.Fatal("unsaved job is queued")
+	}
+	os.Remove(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 대기열이 가득 찬 경우 저장한 파일 삭제 (앞선 작업 파일은 모두 삭제된 상태)
+	queue <- "0000000000000000000000000000000f"
+	if _, err := Create(&Job{RequestID: "job-save"}); err == nil {
+		t.Fatal("job is created with full queue")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Fatalf("job files = %v", files)
+	}
+	<-queue
+	created, err := Create(&Job{RequestID: "job-save"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, created.ID+".json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 완료 상태를 저장할 수 없으면 상태 조회 시 확인 가능
+	job := &Job{ID: created.ID, State: JS_RUNNING}
+	os.RemoveAll(dir)
+	ioutil.WriteFile(dir, nil, 0644)
+	defer func() {
+		os.Remove(dir)
+		os.MkdirAll(dir, 0755)
+	}()
+	finish(job, &Job{Rows: 1}, nil)
+	if job.State != JS_DONE || job.Error == "" {
+		t.Fatalf("job = %+v", job)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	requestID := "job-authorize"
+	query := `{ "access": { "allowedCIDRs": ["10.0.0.0/8"], "clientCertSubjects": ["partner"] } }`
+	if err := storage.SaveDefinition(requestID, json.RawMessage(query), nil); err != nil {
+		t.Fatal(err)
+	}
+	key, _, err := apikey.Issue(requestID, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{RequestID: requestID, KeyID: key.ID, ClientIP: "10.1.2.3", CertSubject: "CN=partner", CertCommonName: "partner"}
+	if err := authorize(job); err != nil {
+		t.Fatal(err)
+	}
+	// 등록 이후 바뀐 접근 정책
+	for name, modify := range map[string]func(*Job){
+		"other network":     func(j *Job) { j.ClientIP = "192.168.0.1" },
+		"no certificate":    func(j *Job) { j.CertSubject, j.CertCommonName = "", "" },
+		"other certificate": func(j *Job) { j.CertSubject, j.CertCommonName = "CN=other", "other" },
+		"other request key": func(j *Job) { j.RequestID = "job-other" },
+		"missing key":       func(j *Job) { j.KeyID = "0000000000000000" },
+	} {
+		copied := *job
+		modify(&copied)
+		if err := authorize(&copied); err == nil {
+			t.Errorf("%s: job is authorized", name)
+		}
+	}
+	// 대기 중에 폐기된 API 키
+	if err := apikey.Revoke(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := authorize(job); err == nil {
+		t.Fatal("job of revoked key is authorized")
+	}
+	if _, err := run(job); err == nil {
+		t.Fatal("job of revoked key runs")
+	}
+}
@@ -0,0 +1,287 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+const (
+	// Signature algorithm
+	ALGORITHM = "Ed25519"
+)
+
+// Export ID format (time and random suffix, e.g. 20260102T150405Z-0123456789abcdef)
+var exportIDPattern = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}Z-[0-9a-f]{16}$`)
+
+// Export manifest (what was exported)
+type Manifest struct {
+	ExportID   string   `json:"exportId"`
+	RequestID  string   `json:"requestId"`
+	ExportedAt string   `json:"exportedAt"`
+	Rows       uint64   `json:"rows"`
+	Columns    []string `json:"columns"`
+	// SHA-256 of the anonymization options of the request definition (as stored in the database)
+	OptionsSHA256 string `json:"optionsSha256"`
+	// Output file (format, compression, encryption and file name of the response)
+	Format          string `json:"format"`
+	Compression     string `json:"compression"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	Encryption      string `json:"encryption,omitempty"`
+	FileName        string `json:"fileName"`
+	// Size and SHA-256 of the response body as sent
+	Size   uint64 `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Signed manifest (signature is over the manifest JSON bytes as stored)
+type SignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Algorithm string          `json:"algorithm"`
+	KeyID     string          `json:"keyId"`
+	Signature string          `json:"signature"`
+}
+
+// Public key for verification
+type PublicKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	// Raw public key (base64) and SubjectPublicKeyInfo (PEM)
+	PublicKey string `json:"publicKey"`
+	PEM       string `json:"pem"`
+}
+
+// Output stream digest (SHA-256 and size of the bytes written)
+type Digest struct {
+	w    io.Writer
+	hash hash.Hash
+	size uint64
+}
+
+var (
+	signingKey     ed25519.PrivateKey
+	signingKeyErr  error
+	signingKeyOnce sync.Once
+)
+
+/* [Function] Create export ID */
+func NewExportID(now time.Time) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix), nil
+}
+
+/* [Function] Whether the export ID is valid (also used as file name) */
+func ValidExportID(exportID string) bool {
+	return exportIDPattern.MatchString(exportID)
+}
+
+/* [Function] Create digest writer of output stream */
+func NewDigest(w io.Writer) *Digest {
+	return &Digest{w: w, hash: sha256.New()}
+}
+
+func (d *Digest) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	// 실제로 전송된 데이터만 반영
+	d.hash.Write(p[:n])
+	d.size += uint64(n)
+	return n, err
+}
+
+/* [Function] SHA-256 of the written bytes (hex) */
+func (d *Digest) Sum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
+
+func (d *Digest) Size() uint64 {
+	return d.size
+}
+
+/* [Function] Sign manifest and save it (resources/manifests/<requestID>/<exportID>.json) */
+func Save(manifest *Manifest) error {
+	if !ValidExportID(manifest.ExportID) {
+		return errors.New("Invalid export ID: " + manifest.ExportID)
+	}
+	key, err := loadSigningKey()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	signed := &SignedManifest{
+		Manifest:  content,
+		Algorithm: ALGORITHM,
+		KeyID:     keyID(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, content)),
+	}
+	output, err := json.Marshal(signed)
+	if err != nil {
+		return err
+	}
+	filePath, err := manifestPath(manifest.RequestID, manifest.ExportID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	// 작성 중인 파일이 조회되지 않도록 임시 파일에 작성 후 이름 변경
+	temp := filePath + ".tmp"
+	if err := ioutil.WriteFile(temp, output, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, filePath)
+}
+
+/* [Function] Read signed manifest of export as stored (the signature is over these bytes, so it is served unchanged) */
+func Read(requestID string, exportID string) ([]byte, error) {
+	if !ValidExportID(exportID) {
+		return nil, os.ErrNotExist
+	}
+	filePath, err := manifestPath(requestID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filePath)
+}
+
+/* [Function] Load signed manifest of export */
+func Load(requestID string, exportID string) (*SignedManifest, error) {
+	content, err := Read(requestID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	signed := new(SignedManifest)
+	if err := json.Unmarshal(content, signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+/* [Function] Public key of the manifest signing key */
+func GetPublicKey() (*PublicKey, error) {
+	key, err := loadSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	public := key.Public().(ed25519.PublicKey)
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{
+		Algorithm: ALGORITHM,
+		KeyID:     keyID(public),
+		PublicKey: base64.StdEncoding.EncodeToString(public),
+		PEM:       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, nil
+}
+
+/* [Internal function] Load signing key (created at the first use if the key file does not exist) */
+func loadSigningKey() (ed25519.PrivateKey, error) {
+	signingKeyOnce.Do(func() {
+		keyFile, err := resolvePath(configs.Get().Manifest.KeyFile)
+		if err != nil {
+			signingKeyErr = err
+			return
+		}
+		content, err := ioutil.ReadFile(keyFile)
+		if os.IsNotExist(err) {
+			signingKey, signingKeyErr = createSigningKey(keyFile)
+			return
+		} else if err != nil {
+			signingKeyErr = err
+			return
+		}
+		block, _ := pem.Decode(content)
+		if block == nil {
+			signingKeyErr = errors.New("Invalid manifest signing key: " + keyFile)
+			return
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			signingKeyErr = err
+			return
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			signingKeyErr = errors.New("Manifest signing key is not an Ed25519 key: " + keyFile)
+			return
+		}
+		signingKey = key
+	})
+	return signingKey, signingKeyErr
+}
+
+/* [Internal function] Create signing key and save it as PKCS #8 PEM */
+func createSigningKey(keyFile string) (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := ioutil.WriteFile(keyFile, buf.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+	log.Print("Created manifest signing key: " + keyFile)
+	return key, nil
+}
+
+/* [Internal function] Key ID (first 8 bytes of SHA-256 of the public key) */
+func keyID(public ed25519.PublicKey) string {
+	digest := sha256.Sum256(public)
+	return hex.EncodeToString(digest[:8])
+}
+
+/* [Internal function] Manifest file path of export */
+func manifestPath(requestID string, exportID string) (string, error) {
+	dir, err := resolvePath(configs.Get().Manifest.Dir)
+	if err != nil {
+		return "", err
+	}
+	// 요청 ID는 디렉토리 이름으로 사용되므로 경로 이동 방지
+	return filepath.Join(dir, filepath.Clean("/"+requestID), exportID+".json"), nil
+}
+
+/* [Internal function] Resolve configured path (relative to the workspace) */
+func resolvePath(configured string) (string, error) {
+	if filepath.IsAbs(configured) {
+		return configured, nil
+	}
+	workspace, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(workspace, configured), nil
+}
@@ -0,0 +1,328 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	// JWT
+	jwt "github.com/golang-jwt/jwt/v4"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+const (
+	// Echo context key of authenticated identity
+	CONTEXT_KEY = "identity"
+	// Roles (a higher role includes the lower roles)
+	ROLE_VIEWER   = "viewer"
+	ROLE_EXPORTER = "exporter"
+	ROLE_ADMIN    = "admin"
+	// Refresh interval of JWKS fetched from the issuer
+	jwksRefresh = time.Hour
+	// Minimum interval of fetching JWKS again for unknown key ID
+	jwksRetry = time.Minute
+)
+
+// Rank of each role
+var roleRanks = map[string]int{
+	ROLE_VIEWER:   1,
+	ROLE_EXPORTER: 2,
+	ROLE_ADMIN:    3,
+}
+
+// Signing algorithms of OIDC providers (HMAC and none are not accepted)
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+var ErrInvalidToken = errors.New("Invalid access token")
+
+// Authenticated user of access token
+type Identity struct {
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+// JSON Web Key Set
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var (
+	// Verification keys by key ID
+	keys      map[string]interface{}
+	keysFrom  time.Time
+	keysMu    sync.Mutex
+	fetchedAt time.Time
+)
+
+/* [Function] Whether OIDC authentication is configured */
+func Enabled() bool {
+	return configs.Get().Auth.OIDC.Issuer != ""
+}
+
+/* [Function] Verify access token (signature by the issuer's JWKS, issuer, audience and expiry) */
+func Verify(token string, now time.Time) (*Identity, error) {
+	config := configs.Get().Auth.OIDC
+	claims := jwt.MapClaims{}
+	// 만료 시간은 now 기준으로 아래에서 확인
+	parser := jwt.NewParser(jwt.WithValidMethods(validMethods), jwt.WithoutClaimsValidation())
+	parsed, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return verificationKey(kid)
+	})
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+	unix := now.Unix()
+	if !claims.VerifyExpiresAt(unix, true) || !claims.VerifyNotBefore(unix, false) {
+		return nil, ErrInvalidToken
+	}
+	// 발급자 URL의 마지막 "/"는 구분하지 않음
+	issuer := strings.TrimSuffix(config.Issuer, "/")
+	if !claims.VerifyIssuer(issuer, true) && !claims.VerifyIssuer(issuer+"/", true) {
+		return nil, ErrInvalidToken
+	}
+	if config.Audience != "" && !claims.VerifyAudience(config.Audience, true) {
+		return nil, ErrInvalidToken
+	}
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	identity.Roles = claimRoles(claims, config.RolesClaim)
+	return identity, nil
+}
+
+/* [Function] Whether the identity has the role (or a higher role) */
+func (i *Identity) HasRole(role string) bool {
+	required, ok := roleRanks[role]
+	if !ok {
+		return false
+	}
+	for _, name := range i.Roles {
+		if roleRanks[name] >= required {
+			return true
+		}
+	}
+	return false
+}
+
+/* [Internal function] Roles of token (claim path separated by ".", e.g. realm_access.roles) */
+func claimRoles(claims jwt.MapClaims, claimPath string) []string {
+	if claimPath == "" {
+		claimPath = "roles"
+	}
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(claimPath, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	var roles []string
+	switch value := value.(type) {
+	case string:
+		// 공백으로 구분된 문자열 (scope 형식)
+		roles = strings.Fields(value)
+	case []interface{}:
+		for _, item := range value {
+			if role, ok := item.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+/* [Internal function] Verification key of key ID (single key is used for token without key ID) */
+func verificationKey(kid string) (interface{}, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if err := loadKeys(false); err != nil {
+		log.Print("Failed to load JWKS: " + err.Error())
+		return nil, err
+	}
+	key := findKey(kid)
+	if key == nil && configs.Get().Auth.OIDC.JWKSFile == "" && time.Since(fetchedAt) > jwksRetry {
+		// 키 교체 후 새로운 키 ID는 JWKS를 다시 조회
+		if err := loadKeys(true); err != nil {
+			log.Print("Failed to load JWKS: " + err.Error())
+			return nil, err
+		}
+		key = findKey(kid)
+	}
+	if key == nil {
+		return nil, errors.New("Unknown signing key: " + kid)
+	}
+	return key, nil
+}
+
+func findKey(kid string) interface{} {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return keys[kid]
+}
+
+/* [Internal function] Load JWKS (local file is reloaded when modified, issuer's JWKS is refreshed periodically) */
+func loadKeys(force bool) error {
+	config := configs.Get().Auth.OIDC
+	var content []byte
+	if config.JWKSFile != "" {
+		file := config.JWKSFile
+		if !filepath.IsAbs(file) {
+			workspace, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			file = filepath.Join(workspace, file)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if keys != nil && info.ModTime().Equal(keysFrom) {
+			return nil
+		}
+		if content, err = ioutil.ReadFile(file); err != nil {
+			return err
+		}
+		keysFrom = info.ModTime()
+	} else {
+		if keys != nil && !force && time.Since(fetchedAt) < jwksRefresh {
+			return nil
+		}
+		// 조회 실패 후에는 잠시 후 다시 조회
+		if keys == nil && time.Since(fetchedAt) < jwksRetry {
+			return errors.New("JWKS of the issuer is not available")
+		}
+		fetchedAt = time.Now()
+		var err error
+		if content, err = fetchJWKS(config.Issuer); err != nil {
+			return err
+		}
+	}
+	set := new(jwks)
+	if err := json.Unmarshal(content, set); err != nil {
+		return err
+	}
+	loaded := make(map[string]interface{}, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			log.Print("Skipped JWKS key " + key.Kid + ": " + err.Error())
+			continue
+		}
+		loaded[key.Kid] = publicKey
+	}
+	keys = loaded
+	return nil
+}
+
+/* [Internal function] Fetch JWKS of issuer (OpenID Connect discovery) */
+func fetchJWKS(issuer string) ([]byte, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	discovery := struct {
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+	if err := getJSON(client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.JWKSURI == "" {
+		return nil, errors.New("jwks_uri is not found in the discovery document of " + issuer)
+	}
+	var content json.RawMessage
+	if err := getJSON(client, discovery.JWKSURI, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func getJSON(client *http.Client, url string, value interface{}) error {
+	res, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.New("Failed to get " + url + ": " + res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(value)
+}
+
+/* [Internal function] Public key of JWK (RSA or EC) */
+func (k *jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("Unsupported curve: " + k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("Point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.New("Unsupported key type: " + k.Kty)
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	// JWT
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/realms/dems/"
+	testAudience = "dems-api"
+)
+
+// Signing key of test tokens (published in the local JWKS file)
+var signingKey *ecdsa.PrivateKey
+
+func TestMain(m *testing.M) {
+	// 설정과 JWKS 파일은 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-oidc")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	if signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		log.Fatal(err)
+	}
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	set := jwks{Keys: []jwk{{
+		Kty: "EC", Kid: "test", Use: "sig", Crv: "P-256",
+		X: encode(signingKey.X.Bytes()), Y: encode(signingKey.Y.Bytes()),
+	}}}
+	config := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"oidc": map[string]string{"issuer": testIssuer, "audience": testAudience, "jwksFile": "jwks.json", "rolesClaim": "realm_access.roles"},
+		},
+	}
+	os.MkdirAll("resources", 0755)
+	for file, value := range map[string]interface{}{"jwks.json": set, filepath.Join("resources", "config.json"): config} {
+		content, _ := json.Marshal(value)
+		if err := ioutil.WriteFile(file, content, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+/* [Internal function] Sign claims with the test key (ES256) */
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+/* [Internal function] Claims of valid access token */
+func validClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":          testIssuer,
+		"aud":          testAudience,
+		"sub":          "user-1",
+		"exp":          now.Add(5 * time.Minute).Unix(),
+		"nbf":          now.Add(-time.Minute).Unix(),
+		"realm_access": map[string]interface{}{"roles": []string{"exporter", "offline_access"}},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	identity, err := Verify(sign(t, validClaims(now)), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "user-1" || !reflect.DeepEqual(identity.Roles, []string{"exporter", "offline_access"}) {
+		t.Fatalf("identity = %+v", identity)
+	}
+	if !identity.HasRole(ROLE_VIEWER) || !identity.HasRole(ROLE_EXPORTER) || identity.HasRole(ROLE_ADMIN) {
+		t.Fatalf("roles of %+v", identity)
+	}
+
+	valid := []func(jwt.MapClaims){
+		// 여러 audience 중 하나
+		func(c jwt.MapClaims) { c["aud"] = []string{"account", testAudience} },
+		// 발급자 URL의 마지막 "/" 생략
+		func(c jwt.MapClaims) { c["iss"] = "https://idp.example.com/realms/dems" },
+		func(c jwt.MapClaims) { delete(c, "nbf") },
+	}
+	for i, modify := range valid {
+		claims := validClaims(now)
+		modify(claims)
+		if _, err := Verify(sign(t, claims), now); err != nil {
+			t.Errorf("valid case %d (%v): %v", i, claims, err)
+		}
+	}
+	invalid := map[string]func(jwt.MapClaims){
+		"other issuer":    func(c jwt.MapClaims) { c["iss"] = "https://idp.example.com/realms/other" },
+		"no issuer":       func(c jwt.MapClaims) { delete(c, "iss") },
+		"other audience":  func(c jwt.MapClaims) { c["aud"] = []string{"account"} },
+		"no audience":     func(c jwt.MapClaims) { delete(c, "aud") },
+		"expired":         func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Second).Unix() },
+		"no expiry":       func(c jwt.MapClaims) { delete(c, "exp") },
+		"not before":      func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Minute).Unix() },
+		"no subject":      func(c jwt.MapClaims) { delete(c, "sub") },
+		"empty subject":   func(c jwt.MapClaims) { c["sub"] = "" },
+		"issuer as array": func(c jwt.MapClaims) { c["iss"] = []string{testIssuer} },
+	}
+	for name, modify := range invalid {
+		claims := validClaims(now)
+		modify(claims)
+		if _, err := Verify(sign(t, claims), now); err != ErrInvalidToken {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	// 검증 시간 기준으로 만료 확인
+	token := sign(t, validClaims(now))
+	if _, err := Verify(token, now.Add(10*time.Minute)); err != ErrInvalidToken {
+		t.Errorf("token verified after expiry: %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	// HMAC 서명은 허용하지 않음
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims(now))
+	hmac.Header["kid"] = "test"
+	signed, _ := hmac.SignedString([]byte("secret"))
+	if _, err := Verify(signed, now); err != ErrInvalidToken {
+		t.Errorf("HS256 token: %v", err)
+	}
+	// JWKS에 없는 키
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	forged := jwt.NewWithClaims(jwt.SigningMethodES256, validClaims(now))
+	forged.Header["kid"] = "test"
+	signed, _ = forged.SignedString(other)
+	if _, err := Verify(signed, now); err != ErrInvalidToken {
+		t.Errorf("token of other key: %v", err)
+	}
+	forged.Header["kid"] = "unknown"
+	signed, _ = forged.SignedString(signingKey)
+	if _, err := Verify(signed, now); err != ErrInvalidToken {
+		t.Errorf("token of unknown key ID: %v", err)
+	}
+	if _, err := Verify("not.a.token", now); err != ErrInvalidToken {
+		t.Errorf("malformed token: %v", err)
+	}
+}
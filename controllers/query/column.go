@@ -0,0 +1,87 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// Source column value type (mapped from driver scan type)
+type ValueType int
+
+const (
+	VT_STRING ValueType = iota
+	VT_INT64
+	VT_FLOAT64
+	VT_BOOL
+	VT_DECIMAL
+	VT_TIME
+)
+
+// Column name and value type of query result
+type ColumnInfo struct {
+	Name string
+	Type ValueType
+	// Decimal precision and scale (VT_DECIMAL only, 0 if unknown)
+	Precision int64
+	Scale     int64
+	// Driver scan type (e.g. driver.Decimal)
+	ScanType string
+}
+
+/* [Function] Get queryed result column types */
+func GetColumnTypes(ctx context.Context, db *sql.DB, dialect Dialect, query *SelectQuery) ([]ColumnInfo, error) {
+	// Derive header query (one row) from query model
+	statement, err := query.HeaderQuery().Build(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, statement.Syntax, statement.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]ColumnInfo, len(cTypes))
+	for i, cType := range cTypes {
+		columns[i] = convertColumnType(cType)
+	}
+	return columns, nil
+}
+
+/* [Internal function] Map driver column type to value type (unknown type is string) */
+func convertColumnType(cType *sql.ColumnType) ColumnInfo {
+	column := ColumnInfo{Name: cType.Name(), Type: VT_STRING}
+	if cType.ScanType() != nil {
+		column.ScanType = cType.ScanType().String()
+	}
+	// DECIMAL은 정밀도가 확인되는 경우만 precision, scale 지정 (HANA floating decimal 등은 0)
+	switch strings.ToUpper(cType.DatabaseTypeName()) {
+	case "DECIMAL", "NUMERIC", "SMALLDECIMAL":
+		if precision, scale, ok := cType.DecimalSize(); ok && precision > 0 && scale >= 0 && scale <= precision {
+			column.Type = VT_DECIMAL
+			column.Precision = precision
+			column.Scale = scale
+			return column
+		}
+	}
+	switch column.ScanType {
+	case "driver.Decimal":
+		column.Type = VT_DECIMAL
+	case "int", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32",
+		"sql.NullInt64", "sql.NullInt32", "sql.NullInt16", "sql.NullByte":
+		column.Type = VT_INT64
+	case "float32", "float64", "sql.NullFloat64":
+		column.Type = VT_FLOAT64
+	case "bool", "sql.NullBool":
+		column.Type = VT_BOOL
+	case "time.Time", "sql.NullTime", "mysql.NullTime":
+		column.Type = VT_TIME
+	}
+	return column
+}
@@ -0,0 +1,160 @@
+package query
+
+import (
+	"database/sql"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+
+	// Driver
+	"github.com/SAP/go-hdb/driver"
+	mysql "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// Data source type (query.json > conn.type)
+	DS_HANA     = "hana"
+	DS_POSTGRES = "postgres"
+	DS_MYSQL    = "mysql"
+	DS_SQLITE   = "sqlite"
+)
+
+// Data source interface
+type DataSource interface {
+	// Create db object using connection info (query.json > conn)
+	Open(connInfo map[string]interface{}) (*sql.DB, error)
+}
+
+// Registered data sources
+var (
+	dataSourceMutex sync.RWMutex
+	dataSources     = make(map[string]DataSource)
+)
+
+func init() {
+	RegisterDataSource(DS_HANA, new(hanaSource))
+	RegisterDataSource(DS_POSTGRES, new(postgresSource))
+	RegisterDataSource(DS_MYSQL, new(mysqlSource))
+	// MariaDB uses the same protocol as MySQL
+	RegisterDataSource("mariadb", new(mysqlSource))
+	RegisterDataSource(DS_SQLITE, new(sqliteSource))
+}
+
+/* [Function] Register data source (type name -> implementation) */
+func RegisterDataSource(dsType string, source DataSource) {
+	dataSourceMutex.Lock()
+	defer dataSourceMutex.Unlock()
+	if source == nil {
+		panic("query: RegisterDataSource source is nil")
+	}
+	dataSources[dsType] = source
+}
+
+/* [Function] Get data source by type name (default is HANA) */
+func GetDataSource(dsType string) (DataSource, error) {
+	// 기존 query.json 파일에는 type 정보가 없으므로 HANA로 처리
+	if dsType == "" {
+		dsType = DS_HANA
+	}
+	dataSourceMutex.RLock()
+	defer dataSourceMutex.RUnlock()
+	source, ok := dataSources[dsType]
+	if !ok {
+		return nil, errors.New("Unsupported data source type: " + dsType)
+	}
+	return source, nil
+}
+
+/* [Internal function] SAP HANA */
+type hanaSource struct{}
+
+func (s *hanaSource) Open(connInfo map[string]interface{}) (*sql.DB, error) {
+	// DSN 생성
+	dsn := url.URL{
+		Scheme: "hdb",
+		User:   url.UserPassword(getConnValue(connInfo, "user"), getConnValue(connInfo, "pwd")),
+		Host:   net.JoinHostPort(getConnValue(connInfo, "host"), getConnValue(connInfo, "port")),
+	}
+	// 커넥터 생성
+	connector, err := driver.NewDSNConnector(dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	// 커넥터 옵션 설정
+	connector.SetFetchSize(512)
+	// 데이터베이스 객체 생성
+	return sql.OpenDB(connector), nil
+}
+
+/* [Internal function] PostgreSQL */
+type postgresSource struct{}
+
+func (s *postgresSource) Open(connInfo map[string]interface{}) (*sql.DB, error) {
+	// conn.database는 스키마로 사용되므로 접속할 데이터베이스는 conn.dbname으로 지정
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(getConnValue(connInfo, "user"), getConnValue(connInfo, "pwd")),
+		Host:   net.JoinHostPort(getConnValue(connInfo, "host"), getConnValue(connInfo, "port")),
+		Path:   "/" + getConnValue(connInfo, "dbname"),
+	}
+	if sslMode := getConnValue(connInfo, "sslmode"); sslMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": []string{sslMode}}.Encode()
+	}
+	return sql.Open("postgres", dsn.String())
+}
+
+/* [Internal function] MySQL / MariaDB */
+type mysqlSource struct{}
+
+func (s *mysqlSource) Open(connInfo map[string]interface{}) (*sql.DB, error) {
+	config := mysql.NewConfig()
+	config.User = getConnValue(connInfo, "user")
+	config.Passwd = getConnValue(connInfo, "pwd")
+	config.Net = "tcp"
+	config.Addr = net.JoinHostPort(getConnValue(connInfo, "host"), getConnValue(connInfo, "port"))
+	// 테이블은 database.table 형태로 지정하므로 기본 데이터베이스는 선택 사항
+	config.DBName = getConnValue(connInfo, "dbname")
+	// 날짜/시간 데이터를 time.Time으로 변환
+	config.ParseTime = true
+	// 커넥터 생성
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+/* [Internal function] SQLite (로컬 테스트 용도) */
+type sqliteSource struct{}
+
+func (s *sqliteSource) Open(connInfo map[string]interface{}) (*sql.DB, error) {
+	filePath := getConnValue(connInfo, "path")
+	if filePath == "" {
+		return nil, errors.New("SQLite data source requires conn.path")
+	}
+	// 반출 서버는 원본 데이터를 변경하지 않으므로 읽기 전용으로 연결
+	dsn := url.URL{
+		Scheme:   "file",
+		Opaque:   filePath,
+		RawQuery: "mode=ro",
+	}
+	return sql.Open("sqlite3", dsn.String())
+}
+
+/* [Internal function] Get connection value as string (JSON 숫자 타입 포함) */
+func getConnValue(connInfo map[string]interface{}, key string) string {
+	switch value := connInfo[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return ""
+	}
+}
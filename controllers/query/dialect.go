@@ -0,0 +1,173 @@
+package query
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SQL dialect interface (데이터 소스별 SQL 문법 차이 처리)
+type Dialect interface {
+	// Dialect name (same as data source type)
+	Name() string
+	// Bind parameter placeholder (index starts from 1)
+	Placeholder(index int) string
+	// Quote identifier (table, column name)
+	QuoteIdentifier(name string) string
+	// Predicate that is true while the legal duration (months, bound parameter) from the date column has not expired
+	LegalDuration(column string, months string) string
+	// Append paging clause (LIMIT, OFFSET) to query syntax
+	Paging(syntax string, firstIndex int) string
+	// Append clause to fetch only one row (used to get header)
+	LimitOne(syntax string) string
+}
+
+// Registered dialects
+var dialects = map[string]Dialect{
+	DS_HANA:     new(hanaDialect),
+	DS_POSTGRES: new(postgresDialect),
+	DS_MYSQL:    new(mysqlDialect),
+	"mariadb":   new(mysqlDialect),
+	DS_SQLITE:   new(sqliteDialect),
+}
+
+/* [Function] Get dialect by data source type (default is HANA) */
+func GetDialect(dsType string) (Dialect, error) {
+	if dsType == "" {
+		dsType = DS_HANA
+	}
+	dialect, ok := dialects[dsType]
+	if !ok {
+		return nil, errors.New("Unsupported SQL dialect: " + dsType)
+	}
+	return dialect, nil
+}
+
+/* [Function] Get dialect for requested export (query.json > conn.type) */
+func LoadDialect(requestID string) (Dialect, error) {
+	options, err := getQueryOption(requestID)
+	if err != nil {
+		return nil, err
+	}
+	conn, ok := options["conn"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Invalid query option (conn)")
+	}
+	dsType, _ := conn["type"].(string)
+	return GetDialect(dsType)
+}
+
+/* [Internal function] Question mark placeholder and LIMIT/OFFSET paging (HANA, MySQL, SQLite) */
+type limitOffsetDialect struct{}
+
+func (d *limitOffsetDialect) Placeholder(index int) string {
+	return "?"
+}
+
+/* [Internal function] Quote identifier using double quotation (SQL standard) */
+func quoteStandard(name string) string {
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
+
+/* [Internal function] SAP HANA */
+type hanaDialect struct {
+	limitOffsetDialect
+}
+
+func (d *hanaDialect) Name() string {
+	return DS_HANA
+}
+
+func (d *hanaDialect) QuoteIdentifier(name string) string {
+	return quoteStandard(name)
+}
+
+func (d *hanaDialect) LegalDuration(column string, months string) string {
+	return "ADD_MONTHS(TO_DATE(" + column + "), " + months + ") > NOW()"
+}
+
+func (d *hanaDialect) Paging(syntax string, firstIndex int) string {
+	return syntax + " LIMIT ? OFFSET ?"
+}
+
+func (d *hanaDialect) LimitOne(syntax string) string {
+	return syntax + " LIMIT 1"
+}
+
+/* [Internal function] PostgreSQL */
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string {
+	return DS_POSTGRES
+}
+
+func (d *postgresDialect) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (d *postgresDialect) QuoteIdentifier(name string) string {
+	return quoteStandard(name)
+}
+
+func (d *postgresDialect) LegalDuration(column string, months string) string {
+	return "CAST(" + column + " AS DATE) + MAKE_INTERVAL(months => " + months + ") > NOW()"
+}
+
+func (d *postgresDialect) Paging(syntax string, firstIndex int) string {
+	return syntax + " LIMIT " + d.Placeholder(firstIndex) + " OFFSET " + d.Placeholder(firstIndex+1)
+}
+
+func (d *postgresDialect) LimitOne(syntax string) string {
+	return syntax + " LIMIT 1"
+}
+
+/* [Internal function] MySQL / MariaDB */
+type mysqlDialect struct {
+	limitOffsetDialect
+}
+
+func (d *mysqlDialect) Name() string {
+	return DS_MYSQL
+}
+
+func (d *mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (d *mysqlDialect) LegalDuration(column string, months string) string {
+	return "DATE_ADD(DATE(" + column + "), INTERVAL " + months + " MONTH) > NOW()"
+}
+
+func (d *mysqlDialect) Paging(syntax string, firstIndex int) string {
+	return syntax + " LIMIT ? OFFSET ?"
+}
+
+func (d *mysqlDialect) LimitOne(syntax string) string {
+	return syntax + " LIMIT 1"
+}
+
+/* [Internal function] SQLite */
+type sqliteDialect struct {
+	limitOffsetDialect
+}
+
+func (d *sqliteDialect) Name() string {
+	return DS_SQLITE
+}
+
+func (d *sqliteDialect) QuoteIdentifier(name string) string {
+	return quoteStandard(name)
+}
+
+func (d *sqliteDialect) LegalDuration(column string, months string) string {
+	// SQLite는 날짜 타입이 없으므로 DATETIME 문자열로 비교
+	return "DATETIME(DATE(" + column + "), '+' || " + months + " || ' months') > DATETIME('now')"
+}
+
+func (d *sqliteDialect) Paging(syntax string, firstIndex int) string {
+	return syntax + " LIMIT ? OFFSET ?"
+}
+
+func (d *sqliteDialect) LimitOne(syntax string) string {
+	return syntax + " LIMIT 1"
+}
@@ -0,0 +1,103 @@
+package query
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// Regenerate golden files (go test ./controllers/query -update)
+var update = flag.Bool("update", false, "update golden files")
+
+/* [Internal function] Query model of a dEMS request (profile table joined with consent table) */
+func goldenQuery(policy QuotePolicy) *SelectQuery {
+	profiles := Table{Schema: "DEMS", Name: "PROFILES"}
+	consents := Table{Schema: "DEMS", Name: "CONSENTS"}
+	return &SelectQuery{
+		Columns: []Column{{Table: profiles, Name: "AGE"}, {Table: profiles, Name: "NAME"}},
+		From:    profiles,
+		Joins: []Join{{
+			Table: consents,
+			Left:  Column{Table: profiles, Name: "PROFILES_ID"},
+			Right: Column{Table: consents, Name: "PROFILES_ID"},
+		}},
+		Where: []Predicate{
+			&Compare{Column: Column{Table: consents, Name: "NAME"}, Operator: "=", Value: 1},
+			&NotExpired{Column: Column{Table: profiles, Name: "LAST_ACCESSED"}, Months: 12},
+		},
+		Quote: policy,
+		Key:   Column{Table: profiles, Name: "PROFILES_ID"},
+	}
+}
+
+func TestDialectGolden(t *testing.T) {
+	for _, dsType := range []string{DS_HANA, DS_POSTGRES, DS_MYSQL, DS_SQLITE} {
+		t.Run(dsType, func(t *testing.T) {
+			dialect, err := GetDialect(dsType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := goldenQuery(QuoteNever)
+			paging := *query
+			paging.Paging = true
+			quoted := goldenQuery(QuoteAlways)
+			cases := []struct {
+				name  string
+				query *SelectQuery
+			}{
+				{"select", query},
+				{"select paging", &paging},
+				{"select quoted", quoted},
+				{"count", query.CountQuery()},
+				{"header", query.HeaderQuery()},
+				{"key bounds", query.KeyBoundsQuery()},
+				{"partition", query.PartitionQuery(Partition{Where: []Predicate{
+					&Compare{Column: query.Key, Operator: ">=", Value: int64(100)},
+					&Compare{Column: query.Key, Operator: "<", Value: int64(200)},
+				}})},
+				{"key quantiles", query.KeyQuantilesQuery(4)},
+				{"partition single", query.PartitionQuery(Partition{})},
+				{"partition null", query.PartitionQuery(Partition{Where: []Predicate{&IsNull{Column: query.Key}}})},
+				{"partition quoted", quoted.PartitionQuery(Partition{Where: []Predicate{&Compare{Column: quoted.Key, Operator: "<", Value: int64(100)}}})},
+			}
+			var buf bytes.Buffer
+			for _, c := range cases {
+				statement, err := c.query.Build(dialect)
+				if err != nil {
+					t.Fatalf("%s: %v", c.name, err)
+				}
+				fmt.Fprintf(&buf, "-- %s\n%s\n-- args: %v\n\n", c.name, statement.Syntax, statement.Args)
+			}
+
+			golden := filepath.Join("testdata", dsType+".golden")
+			if *update {
+				if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), expected) {
+				t.Errorf("SQL of %s does not match %s\n--- got\n%s--- want\n%s", dsType, golden, buf.String(), expected)
+			}
+		})
+	}
+}
+
+func TestGetDialect(t *testing.T) {
+	// 기존 query.json에는 type이 없으므로 HANA
+	if dialect, err := GetDialect(""); err != nil || dialect.Name() != DS_HANA {
+		t.Fatalf("default dialect = %v (%v)", dialect, err)
+	}
+	if dialect, err := GetDialect("mariadb"); err != nil || dialect.Name() != DS_MYSQL {
+		t.Fatalf("mariadb dialect = %v (%v)", dialect, err)
+	}
+	if _, err := GetDialect("oracle"); err == nil {
+		t.Fatal("unsupported dialect is accepted")
+	}
+}
@@ -0,0 +1,369 @@
+package query
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Identifier quoting policy
+type QuotePolicy int
+
+const (
+	// Identifiers are written as-is, so only plain identifiers are allowed (기존 query.json과 호환)
+	QuoteNever QuotePolicy = iota
+	// Identifiers are always quoted by dialect (case-sensitive)
+	QuoteAlways
+)
+
+// Plain (unquoted) identifier format
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$#]*$`)
+
+// Comparison operators allowed in predicates
+var compareOperators = map[string]bool{
+	"=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// Table reference (schema.table)
+type Table struct {
+	Schema string
+	Name   string
+}
+
+// Column reference (schema.table.column)
+type Column struct {
+	Table Table
+	Name  string
+}
+
+// Inner join condition (Left = Right)
+type Join struct {
+	Table Table
+	Left  Column
+	Right Column
+}
+
+// Predicate interface (WHERE 조건)
+type Predicate interface {
+	render(b *queryBuilder) error
+}
+
+// Compare column with bound value (e.g. CONSENT.NAME = ?)
+type Compare struct {
+	Column   Column
+	Operator string
+	Value    interface{}
+}
+
+// Column is NULL
+type IsNull struct {
+	Column Column
+}
+
+// Column is not NULL
+type IsNotNull struct {
+	Column Column
+}
+
+// Legal duration (months) from date column has not expired
+type NotExpired struct {
+	Column Column
+	Months int
+}
+
+// Order by column
+type Order struct {
+	Column Column
+	Desc   bool
+}
+
+// Aggregate function (MIN, MAX, COUNT)
+type aggregate struct {
+	function string
+	column   Column
+}
+
+// Select query model
+type SelectQuery struct {
+	Columns []Column
+	From    Table
+	Joins   []Join
+	// Predicates are combined with AND
+	Where   []Predicate
+	OrderBy []Order
+	// Append LIMIT/OFFSET placeholders (values are bound at execution)
+	Paging bool
+	// Identifier quoting policy
+	Quote QuotePolicy
+	// Partition key column (keyset pagination)
+	Key Column
+	// Partition by sampled key quantiles instead of MIN/MAX range
+	KeySampling bool
+
+	aggregates []aggregate
+	count      bool
+	limitOne   bool
+	// Number of key quantiles (NTILE)
+	tiles int
+}
+
+// Rendered query syntax and bound values
+type Statement struct {
+	Syntax string
+	Args   []interface{}
+}
+
+/* [Function] Derive query to count the result rows */
+func (q SelectQuery) CountQuery() *SelectQuery {
+	q.count = true
+	q.OrderBy = nil
+	q.Paging = false
+	q.limitOne = false
+	return &q
+}
+
+/* [Function] Derive query to get MIN, MAX, COUNT of key column */
+func (q SelectQuery) KeyBoundsQuery() *SelectQuery {
+	q.aggregates = []aggregate{{"MIN", q.Key}, {"MAX", q.Key}, {"COUNT", q.Key}}
+	q.OrderBy = nil
+	q.Paging = false
+	q.limitOne = false
+	return &q
+}
+
+/* [Function] Derive query to get the first key of each quantile (n quantiles by NTILE, computed in the database) */
+func (q SelectQuery) KeyQuantilesQuery(n int) *SelectQuery {
+	q.tiles = n
+	q.Where = append(append(make([]Predicate, 0, len(q.Where)+1), q.Where...), &IsNotNull{Column: q.Key})
+	q.OrderBy = nil
+	q.Paging = false
+	q.limitOne = false
+	return &q
+}
+
+/* [Function] Derive query to fetch only one row (used to get header) */
+func (q SelectQuery) HeaderQuery() *SelectQuery {
+	q.limitOne = true
+	q.Paging = false
+	return &q
+}
+
+/* [Function] Derive query of partition (key range condition, ordered by key) */
+func (q SelectQuery) PartitionQuery(partition Partition) *SelectQuery {
+	// 분할하지 않은 경우 키 컬럼을 사용하지 않음 (키 컬럼이 없는 테이블)
+	if len(partition.Where) == 0 {
+		return &q
+	}
+	q.Where = append(append(make([]Predicate, 0, len(q.Where)+len(partition.Where)), q.Where...), partition.Where...)
+	q.OrderBy = []Order{{Column: q.Key}}
+	return &q
+}
+
+/* [Function] Render query model by dialect */
+func (q *SelectQuery) Build(dialect Dialect) (*Statement, error) {
+	b := &queryBuilder{dialect: dialect, policy: q.Quote}
+	// Select list
+	b.buf.WriteString("SELECT ")
+	if q.count {
+		b.buf.WriteString("COUNT(*)")
+	} else if q.tiles > 0 {
+		// 키 값과 분위 번호 (분위별 최소 키 값은 외부 쿼리에서 조회)
+		if err := b.writeColumn(q.Key); err != nil {
+			return nil, err
+		}
+		b.buf.WriteString(" AS PARTITION_KEY, NTILE(" + strconv.Itoa(q.tiles) + ") OVER (ORDER BY ")
+		if err := b.writeColumn(q.Key); err != nil {
+			return nil, err
+		}
+		b.buf.WriteString(") AS PARTITION_TILE")
+	} else if len(q.aggregates) > 0 {
+		for i, aggregate := range q.aggregates {
+			if i > 0 {
+				b.buf.WriteString(", ")
+			}
+			b.buf.WriteString(aggregate.function + "(")
+			if err := b.writeColumn(aggregate.column); err != nil {
+				return nil, err
+			}
+			b.buf.WriteString(")")
+		}
+	} else {
+		if len(q.Columns) == 0 {
+			return nil, errors.New("No columns to select")
+		}
+		for i, column := range q.Columns {
+			if i > 0 {
+				b.buf.WriteString(", ")
+			}
+			if err := b.writeColumn(column); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// From
+	b.buf.WriteString(" FROM ")
+	if err := b.writeTable(q.From); err != nil {
+		return nil, err
+	}
+	// Inner join
+	for _, join := range q.Joins {
+		b.buf.WriteString(" INNER JOIN ")
+		if err := b.writeTable(join.Table); err != nil {
+			return nil, err
+		}
+		b.buf.WriteString(" ON ")
+		if err := b.writeColumn(join.Left); err != nil {
+			return nil, err
+		}
+		b.buf.WriteString("=")
+		if err := b.writeColumn(join.Right); err != nil {
+			return nil, err
+		}
+	}
+	// Condition
+	for i, predicate := range q.Where {
+		if i == 0 {
+			b.buf.WriteString(" WHERE ")
+		} else {
+			b.buf.WriteString(" AND ")
+		}
+		if err := predicate.render(b); err != nil {
+			return nil, err
+		}
+	}
+	// Order
+	for i, order := range q.OrderBy {
+		if i == 0 {
+			b.buf.WriteString(" ORDER BY ")
+		} else {
+			b.buf.WriteString(", ")
+		}
+		if err := b.writeColumn(order.Column); err != nil {
+			return nil, err
+		}
+		if order.Desc {
+			b.buf.WriteString(" DESC")
+		}
+	}
+
+	syntax := b.buf.String()
+	if q.Paging {
+		syntax = dialect.Paging(syntax, len(b.args)+1)
+	} else if q.limitOne {
+		syntax = dialect.LimitOne(syntax)
+	}
+	if q.tiles > 0 {
+		syntax = "SELECT MIN(PARTITION_KEY) FROM (" + syntax + ") PARTITION_TILES GROUP BY PARTITION_TILE ORDER BY PARTITION_TILE"
+	}
+	return &Statement{Syntax: syntax, Args: b.args}, nil
+}
+
+func (p *Compare) render(b *queryBuilder) error {
+	if !compareOperators[p.Operator] {
+		return errors.New("Unsupported operator: " + p.Operator)
+	}
+	if err := b.writeColumn(p.Column); err != nil {
+		return err
+	}
+	b.buf.WriteString(" " + p.Operator + " ")
+	b.bind(p.Value)
+	return nil
+}
+
+func (p *IsNull) render(b *queryBuilder) error {
+	if err := b.writeColumn(p.Column); err != nil {
+		return err
+	}
+	b.buf.WriteString(" IS NULL")
+	return nil
+}
+
+func (p *IsNotNull) render(b *queryBuilder) error {
+	if err := b.writeColumn(p.Column); err != nil {
+		return err
+	}
+	b.buf.WriteString(" IS NOT NULL")
+	return nil
+}
+
+func (p *NotExpired) render(b *queryBuilder) error {
+	column, err := b.column(p.Column)
+	if err != nil {
+		return err
+	}
+	b.args = append(b.args, p.Months)
+	b.buf.WriteString(b.dialect.LegalDuration(column, b.dialect.Placeholder(len(b.args))))
+	return nil
+}
+
+/* [Internal function] Query syntax builder */
+type queryBuilder struct {
+	dialect Dialect
+	policy  QuotePolicy
+	buf     bytes.Buffer
+	args    []interface{}
+}
+
+/* [Internal function] Render identifier (validated by quoting policy) */
+func (b *queryBuilder) identifier(name string) (string, error) {
+	switch b.policy {
+	case QuoteAlways:
+		if name == "" || strings.ContainsAny(name, "\x00\r\n") {
+			return "", errors.New("Invalid identifier: " + name)
+		}
+		return b.dialect.QuoteIdentifier(name), nil
+	default:
+		if !plainIdentifier.MatchString(name) {
+			return "", errors.New("Invalid identifier: " + name)
+		}
+		return name, nil
+	}
+}
+
+/* [Internal function] Render table reference */
+func (b *queryBuilder) table(table Table) (string, error) {
+	name, err := b.identifier(table.Name)
+	if err != nil || table.Schema == "" {
+		return name, err
+	}
+	schema, err := b.identifier(table.Schema)
+	if err != nil {
+		return "", err
+	}
+	return schema + "." + name, nil
+}
+
+/* [Internal function] Render column reference */
+func (b *queryBuilder) column(column Column) (string, error) {
+	name, err := b.identifier(column.Name)
+	if err != nil || column.Table.Name == "" {
+		return name, err
+	}
+	table, err := b.table(column.Table)
+	if err != nil {
+		return "", err
+	}
+	return table + "." + name, nil
+}
+
+/* [Internal function] Write table reference */
+func (b *queryBuilder) writeTable(table Table) error {
+	rendered, err := b.table(table)
+	b.buf.WriteString(rendered)
+	return err
+}
+
+/* [Internal function] Write column reference */
+func (b *queryBuilder) writeColumn(column Column) error {
+	rendered, err := b.column(column)
+	b.buf.WriteString(rendered)
+	return err
+}
+
+/* [Internal function] Write placeholder and bind value */
+func (b *queryBuilder) bind(value interface{}) {
+	b.args = append(b.args, value)
+	b.buf.WriteString(b.dialect.Placeholder(len(b.args)))
+}
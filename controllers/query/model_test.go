@@ -0,0 +1,115 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuoteNeverRejectsIdentifiers(t *testing.T) {
+	dialect, _ := GetDialect(DS_HANA)
+	for _, name := range []string{"", "NAME; DROP TABLE PROFILES", "NAME--", "A B", `"NAME"`, "1NAME", "NAME)", "이름"} {
+		queries := map[string]*SelectQuery{
+			"column": {Columns: []Column{{Name: name}}, From: Table{Name: "PROFILES"}},
+			"table":  {Columns: []Column{{Name: "AGE"}}, From: Table{Name: name}},
+			"schema": {Columns: []Column{{Name: "AGE"}}, From: Table{Schema: name, Name: "PROFILES"}},
+			"predicate": {Columns: []Column{{Name: "AGE"}}, From: Table{Name: "PROFILES"},
+				Where: []Predicate{&Compare{Column: Column{Name: name}, Operator: "=", Value: 1}}},
+		}
+		for position, query := range queries {
+			if position == "schema" && name == "" {
+				// 스키마를 생략한 경우
+				continue
+			}
+			if _, err := query.Build(dialect); err == nil {
+				t.Errorf("%s %q is accepted", position, name)
+			}
+		}
+	}
+	// 일반 식별자는 그대로 사용
+	query := &SelectQuery{Columns: []Column{{Name: "A_1$#"}}, From: Table{Schema: "_S", Name: "T"}}
+	statement, err := query.Build(dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statement.Syntax != "SELECT A_1$# FROM _S.T" {
+		t.Fatalf("syntax = %s", statement.Syntax)
+	}
+}
+
+func TestQuoteAlways(t *testing.T) {
+	for dsType, expected := range map[string]string{
+		DS_POSTGRES: `SELECT "my ""col""" FROM "s"."t"`,
+		DS_MYSQL:    "SELECT `my \"col\"` FROM `s`.`t`",
+	} {
+		dialect, _ := GetDialect(dsType)
+		query := &SelectQuery{Columns: []Column{{Name: `my "col"`}}, From: Table{Schema: "s", Name: "t"}, Quote: QuoteAlways}
+		statement, err := query.Build(dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statement.Syntax != expected {
+			t.Errorf("%s: syntax = %s", dsType, statement.Syntax)
+		}
+	}
+	// 따옴표로 감싸도 줄바꿈 및 NUL 문자는 허용하지 않음
+	dialect, _ := GetDialect(DS_POSTGRES)
+	for _, name := range []string{"", "a\nb", "a\x00b"} {
+		query := &SelectQuery{Columns: []Column{{Name: name}}, From: Table{Name: "t"}, Quote: QuoteAlways}
+		if _, err := query.Build(dialect); err == nil {
+			t.Errorf("quoted identifier %q is accepted", name)
+		}
+	}
+}
+
+func TestBoundParameters(t *testing.T) {
+	injection := "1' OR '1'='1"
+	query := &SelectQuery{
+		Columns: []Column{{Name: "AGE"}},
+		From:    Table{Name: "PROFILES"},
+		Where: []Predicate{
+			&Compare{Column: Column{Name: "NAME"}, Operator: "=", Value: injection},
+			&NotExpired{Column: Column{Name: "LAST_ACCESSED"}, Months: 6},
+			&Compare{Column: Column{Name: "AGE"}, Operator: ">=", Value: int64(20)},
+		},
+		Paging: true,
+	}
+	// 조건 값은 쿼리 문자열에 포함되지 않고 순서대로 바인딩
+	for dsType, placeholders := range map[string][]string{
+		DS_HANA:     {"?", "?", "?", "?", "?"},
+		DS_POSTGRES: {"$1", "$2", "$3", "$4", "$5"},
+		DS_MYSQL:    {"?", "?", "?", "?", "?"},
+		DS_SQLITE:   {"?", "?", "?", "?", "?"},
+	} {
+		dialect, _ := GetDialect(dsType)
+		statement, err := query.Build(dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(statement.Syntax, injection) || strings.Contains(statement.Syntax, "'1'") {
+			t.Errorf("%s: value is rendered into syntax: %s", dsType, statement.Syntax)
+		}
+		if !reflect.DeepEqual(statement.Args, []interface{}{injection, 6, int64(20)}) {
+			t.Errorf("%s: args = %v", dsType, statement.Args)
+		}
+		if !strings.HasPrefix(statement.Syntax, "SELECT AGE FROM PROFILES WHERE NAME = "+placeholders[0]+" AND ") ||
+			!strings.Contains(statement.Syntax, " AND AGE >= "+placeholders[2]) ||
+			!strings.HasSuffix(statement.Syntax, " LIMIT "+placeholders[3]+" OFFSET "+placeholders[4]) {
+			t.Errorf("%s: syntax = %s", dsType, statement.Syntax)
+		}
+	}
+}
+
+func TestUnsupportedOperator(t *testing.T) {
+	dialect, _ := GetDialect(DS_HANA)
+	for _, operator := range []string{"LIKE", "= 1 OR 1 =", ""} {
+		query := &SelectQuery{
+			Columns: []Column{{Name: "AGE"}},
+			From:    Table{Name: "PROFILES"},
+			Where:   []Predicate{&Compare{Column: Column{Name: "NAME"}, Operator: operator, Value: "x"}},
+		}
+		if _, err := query.Build(dialect); err == nil {
+			t.Errorf("operator %q is accepted", operator)
+		}
+	}
+}
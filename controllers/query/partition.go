@@ -0,0 +1,187 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/bits"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Export partition (key range condition added to query)
+type Partition struct {
+	Where []Predicate
+}
+
+/* [Function] Split query result into key ranges (keyset pagination) */
+func CreatePartitions(ctx context.Context, db *sql.DB, dialect Dialect, query *SelectQuery, blockSize uint64, totalSize uint64) ([]Partition, error) {
+	// 분할 개수 계산 (blockSize 단위)
+	nPart := totalSize / blockSize
+	if totalSize%blockSize > 0 {
+		nPart += 1
+	}
+	// 데이터가 없거나 분할이 필요 없는 경우
+	if nPart <= 1 {
+		return []Partition{{}}, nil
+	}
+
+	// 키 컬럼의 최소값, 최대값, NULL이 아닌 데이터 수 조회
+	statement, err := query.KeyBoundsQuery().Build(dialect)
+	if err != nil {
+		return nil, err
+	}
+	var minKey, maxKey interface{}
+	var keyCount uint64
+	if err := db.QueryRowContext(ctx, statement.Syntax, statement.Args...).Scan(&minKey, &maxKey, &keyCount); err != nil {
+		// 키 컬럼이 없는 테이블은 반출을 시작하기 전에 실패
+		return nil, errors.New("Failed to get range of partition key " + query.Key.Name + " (conn.keyColumn): " + err.Error())
+	}
+
+	// 키 범위 경계값 생성 (정수 키는 MIN/MAX 범위를 균등 분할, 그 외는 데이터베이스에서 계산한 분위수 사용)
+	var bounds []interface{}
+	minInt, minOk := toInt64(minKey)
+	maxInt, maxOk := toInt64(maxKey)
+	if !query.KeySampling && minOk && maxOk {
+		bounds = rangeBounds(minInt, maxInt, nPart)
+	} else {
+		// 분위수 계산은 윈도우 함수가 필요하므로 지원하지 않는 데이터베이스는 반출 전에 실패
+		if err := checkWindowFunctions(ctx, db, dialect); err != nil {
+			return nil, err
+		}
+		bounds, err = quantileBounds(ctx, db, dialect, query, nPart)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 경계값이 없으면 분할하지 않음 (NULL 키 포함)
+	if len(bounds) == 0 {
+		return []Partition{{}}, nil
+	}
+
+	// 경계값을 이용하여 분할 조건 생성 ([이전 경계값, 다음 경계값))
+	partitions := make([]Partition, 0, len(bounds)+2)
+	for i := 0; i <= len(bounds); i++ {
+		partition := Partition{}
+		if i > 0 {
+			partition.Where = append(partition.Where, &Compare{Column: query.Key, Operator: ">=", Value: bounds[i-1]})
+		}
+		if i < len(bounds) {
+			partition.Where = append(partition.Where, &Compare{Column: query.Key, Operator: "<", Value: bounds[i]})
+		}
+		partitions = append(partitions, partition)
+	}
+	// 키 값이 NULL인 데이터는 범위 조건에 포함되지 않으므로 별도로 처리
+	if keyCount < totalSize {
+		partitions = append(partitions, Partition{Where: []Predicate{&IsNull{Column: query.Key}}})
+	}
+	return partitions, nil
+}
+
+/* [Internal function] Divide integer key range [min, max] into n ranges */
+func rangeBounds(minKey int64, maxKey int64, nPart uint64) []interface{} {
+	// 범위가 int64를 넘을 수 있으므로 uint64로 계산 (2의 보수이므로 차이는 정확함)
+	span := uint64(maxKey) - uint64(minKey)
+	step := span/nPart + 1
+	bounds := make([]interface{}, 0, nPart-1)
+	for i := uint64(1); i < nPart; i++ {
+		hi, offset := bits.Mul64(step, i)
+		if hi != 0 || offset > span {
+			break
+		}
+		bounds = append(bounds, int64(uint64(minKey)+offset))
+	}
+	return bounds
+}
+
+/* [Internal function] Check window function (NTILE) support of the data source (MySQL 8.0, MariaDB 10.2 or later) */
+func checkWindowFunctions(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	if dialect.Name() != DS_MYSQL {
+		return nil
+	}
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return err
+	}
+	if !windowFunctionVersion(version) {
+		return errors.New("Quantile partitioning requires window functions (MySQL 8.0, MariaDB 10.2 or later), server version is " + version + ": use an integer key column without conn.partition")
+	}
+	return nil
+}
+
+/* [Internal function] Whether MySQL or MariaDB server version supports window functions */
+func windowFunctionVersion(version string) bool {
+	minMajor, minMinor := 8, 0
+	if strings.Contains(version, "MariaDB") {
+		// 복제 호환을 위해 "5.5.5-" 접두어가 붙는 경우
+		version = strings.TrimPrefix(version, "5.5.5-")
+		minMajor, minMinor = 10, 2
+	}
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return false
+	}
+	return major > minMajor || (major == minMajor && minor >= minMinor)
+}
+
+/* [Internal function] First key of each quantile except the first (NTILE in the database, keys are not transferred) */
+func quantileBounds(ctx context.Context, db *sql.DB, dialect Dialect, query *SelectQuery, nPart uint64) ([]interface{}, error) {
+	// 데이터베이스에서 조건에 맞는 모든 키를 한 번 정렬 (키 컬럼에 인덱스가 없으면 전체 테이블 조회 및 정렬)
+	statement, err := query.KeyQuantilesQuery(int(nPart)).Build(dialect)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, statement.Syntax, statement.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bounds := make([]interface{}, 0, nPart-1)
+	var prev interface{}
+	first := true
+	for rows.Next() {
+		var key interface{}
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		// 첫 번째 분위는 최소값부터 시작하므로 경계값이 아님
+		if first {
+			first = false
+			prev = key
+			continue
+		}
+		// 같은 키 값이 여러 분위에 걸친 경우 하나의 범위로 처리
+		if reflect.DeepEqual(key, prev) {
+			continue
+		}
+		bounds = append(bounds, key)
+		prev = key
+	}
+	return bounds, rows.Err()
+}
+
+/* [Internal function] Convert scanned key value to int64 */
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case []byte:
+		parsed, err := strconv.ParseInt(string(v), 10, 64)
+		return parsed, err == nil
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		return parsed, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,144 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestRangeBounds(t *testing.T) {
+	cases := []struct {
+		min, max int64
+		nPart    uint64
+	}{
+		{1, 10, 3},
+		{0, 0, 2},
+		{-5, 5, 4},
+		{math.MinInt64, math.MaxInt64, 4},
+		{math.MinInt64, math.MaxInt64, 2},
+		{-1, math.MaxInt64, 3},
+		{math.MaxInt64 - 10, math.MaxInt64, 5},
+		{math.MinInt64, math.MinInt64 + 2, 7},
+	}
+	for _, c := range cases {
+		bounds := rangeBounds(c.min, c.max, c.nPart)
+		if uint64(len(bounds)) >= c.nPart {
+			t.Errorf("[%d, %d] / %d: %d bounds", c.min, c.max, c.nPart, len(bounds))
+		}
+		// 경계값은 (min, max] 범위에서 증가 (범위가 겹치거나 누락되지 않음)
+		prev := c.min
+		for _, bound := range bounds {
+			value := bound.(int64)
+			if value <= prev || value > c.max {
+				t.Errorf("[%d, %d] / %d: bounds %v", c.min, c.max, c.nPart, bounds)
+				break
+			}
+			prev = value
+		}
+	}
+	if bounds := rangeBounds(1, 10, 3); fmt.Sprint(bounds) != "[5 9]" {
+		t.Errorf("bounds = %v", bounds)
+	}
+	if bounds := rangeBounds(math.MinInt64, math.MaxInt64, 2); fmt.Sprint(bounds) != "[0]" {
+		t.Errorf("bounds = %v", bounds)
+	}
+}
+
+func TestCreatePartitions(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// 메모리 데이터베이스는 연결마다 다르므로 연결 하나만 사용
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE T (ID INTEGER, CODE TEXT, V INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for i := 0; i < 1000; i++ {
+		// 키 값이 NULL인 데이터와 같은 키 값이 여러 번 나오는 데이터 포함
+		var id, code interface{} = int64(i * 37), fmt.Sprintf("c%03d", i/25)
+		if i%50 == 0 {
+			id, code = nil, nil
+		}
+		if _, err := db.Exec(`INSERT INTO T VALUES (?, ?, ?)`, id, code, i); err != nil {
+			t.Fatal(err)
+		}
+		total++
+	}
+	dialect, _ := GetDialect(DS_SQLITE)
+	for _, c := range []struct {
+		key      string
+		sampling bool
+	}{{"ID", false}, {"ID", true}, {"CODE", false}, {"CODE", true}} {
+		query := &SelectQuery{Columns: []Column{{Name: "V"}}, From: Table{Name: "T"}, Key: Column{Name: c.key}, KeySampling: c.sampling}
+		partitions, err := CreatePartitions(context.Background(), db, dialect, query, 100, uint64(total))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(partitions) < 2 {
+			t.Errorf("%s (sampling %v): %d partitions", c.key, c.sampling, len(partitions))
+		}
+		// 모든 데이터가 한 번씩만 조회됨
+		seen := make(map[int64]bool, total)
+		for _, partition := range partitions {
+			statement, err := query.PartitionQuery(partition).Build(dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := db.Query(statement.Syntax, statement.Args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for rows.Next() {
+				var v int64
+				if err := rows.Scan(&v); err != nil {
+					t.Fatal(err)
+				}
+				if seen[v] {
+					t.Errorf("%s (sampling %v): row %d in two partitions", c.key, c.sampling, v)
+				}
+				seen[v] = true
+			}
+			rows.Close()
+		}
+		if len(seen) != total {
+			t.Errorf("%s (sampling %v): %d of %d rows", c.key, c.sampling, len(seen), total)
+		}
+	}
+
+	// 분할하지 않는 경우 키 컬럼이 없어도 조회 가능, 분할하는 경우 키 컬럼 오류
+	query := &SelectQuery{Columns: []Column{{Name: "V"}}, From: Table{Name: "T"}, Key: Column{Name: "PROFILES_ID"}}
+	partitions, err := CreatePartitions(context.Background(), db, dialect, query, 100, 50)
+	if err != nil || len(partitions) != 1 {
+		t.Fatalf("partitions = %v (%v)", partitions, err)
+	}
+	statement, _ := query.PartitionQuery(partitions[0]).Build(dialect)
+	if _, err := db.Exec(statement.Syntax, statement.Args...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreatePartitions(context.Background(), db, dialect, query, 100, uint64(total)); err == nil {
+		t.Fatal("missing key column is accepted")
+	}
+}
+
+func TestWindowFunctionVersion(t *testing.T) {
+	cases := map[string]bool{
+		"5.7.44-log":                  false,
+		"8.0.36":                      true,
+		"8.4.0":                       true,
+		"10.1.48-MariaDB":             false,
+		"10.2.44-MariaDB-1:10.2.44":   true,
+		"5.5.5-10.11.6-MariaDB":       true,
+		"5.5.5-10.0.38-MariaDB-0+deb": false,
+		"invalid":                     false,
+	}
+	for version, expected := range cases {
+		if supported := windowFunctionVersion(version); supported != expected {
+			t.Errorf("%s: supported = %v", version, supported)
+		}
+	}
+}
@@ -1,22 +1,22 @@
 package query
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	_ "fmt"
 	"log"
-	"math/big"
-	"os"
-	"path"
-	"runtime"
+	"sort"
 	"strconv"
-	"strings"
-	"time"
 
 	// Driver
 	"github.com/SAP/go-hdb/driver"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/storage"
+	"dems-api-server/controllers/worker"
 )
 
 /* [Function] Create db object (using connector) */
@@ -42,34 +42,32 @@ func CreateConnection_old(host string, port string, user string, pwd string) (*s
 }
 
 /* [Function] Create db object (using connector) */
-func CreateConnection(requestID string) (*sql.DB, error) {
-	// 요청된 requestID에 대한 반출 조회
-	optionfilePath, err := checkFileExistance(requestID, "query.json")
-	if err != nil {
-		return nil, err
-	}
-	// 데이터베이스 연결 정보 및 반출 처리 옵션 읽어오기
-	options, err := getOptionFile(optionfilePath)
+func CreateConnection(ctx context.Context, requestID string) (*sql.DB, error) {
+	// 요청된 requestID에 대한 데이터베이스 연결 정보 및 반출 처리 옵션 읽어오기
+	options, err := getQueryOption(requestID)
 	if err != nil {
 		return nil, err
 	}
 	// 데이터베이스 연결 정보 추출
 	connInfo := options["conn"].(map[string]interface{})
 
-	// DSN 생성
-	dsn := "hdb://" + connInfo["user"].(string) + ":" + connInfo["pwd"].(string) + "@" + connInfo["host"].(string) + ":" + connInfo["port"].(string)
-	// 커넥터 생성
-	connector, err := driver.NewDSNConnector(dsn)
+	// 연결 정보의 type에 따라 데이터 소스 선택
+	dsType, _ := connInfo["type"].(string)
+	source, err := GetDataSource(dsType)
 	if err != nil {
 		return nil, err
 	}
-	// 커넥터 옵션 설정
-	connector.SetFetchSize(512)
 	// 데이터베이스 객체 생성
-	db := sql.OpenDB(connector)
+	db, err := source.Open(connInfo)
+	if err != nil {
+		return nil, err
+	}
+	// 요청 단위 동시 쿼리 수만큼 연결 수 제한
+	db.SetMaxOpenConns(configs.Get().Worker.QueryPerExport)
 	// 연결 테스트
-	err = db.Ping()
+	err = db.PingContext(ctx)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 	// Return
@@ -77,189 +75,158 @@ func CreateConnection(requestID string) (*sql.DB, error) {
 }
 
 /* [Function] Query */
-func ExecuteQuery(db *sql.DB, syntax string, blockSize uint64, nProc uint64, dataQueue chan<- []string, nProcQuery chan<- bool) (bool, error) {
-	// 멀티 프로세싱을 위해 Max proc 값 설정
-	runtime.GOMAXPROCS(runtime.NumCPU() * 4)
-
-	// 분할 쿼리를 위해 limit, offset 옵션을 추가하도록 쿼리문 수정
-	var buf bytes.Buffer
-	buf.WriteString(syntax)
-	buf.WriteString(" LIMIT ? OFFSET ?")
-	syntax = buf.String()
-	// 수정된 쿼리문을 이용하여 쿼리 준비
-	stmt, err := db.Prepare(syntax)
-	if err != nil {
-		return false, err
+func ExecuteQuery(ctx context.Context, db *sql.DB, dialect Dialect, query *SelectQuery, partitions []Partition, dataQueue chan<- Row, nProcQuery chan<- error) (bool, error) {
+	// 분할 쿼리를 위해 키 범위 조건과 정렬 조건을 추가하도록 쿼리 수정
+	statements := make([]*Statement, len(partitions))
+	for i, partition := range partitions {
+		statement, err := query.PartitionQuery(partition).Build(dialect)
+		if err != nil {
+			return false, err
+		}
+		statements[i] = statement
 	}
 
-	// 쿼리 수행 (병렬 처리)
-	for i := uint64(0); i < nProc; i++ {
-		go parallelProcess(stmt, dataQueue, nProcQuery, blockSize, (i * blockSize))
+	// 쿼리 수행 (요청 단위 및 서버 전체 동시 쿼리 수 제한)
+	group := worker.QueryPool().NewGroup(ctx, configs.Get().Worker.QueryPerExport)
+	for _, statement := range statements {
+		statement := statement
+		group.Go(func() {
+			parallelProcess(ctx, db, statement, dataQueue, nProcQuery)
+		})
 	}
 
 	return true, nil
 }
 
-/* [Function] Create query syntax (dMES 전용) */
-func CreateQuerySyntax(requestID string) (string, error) {
-	// 요청된 requestID에 대한 반출 조회
-	optionfilePath, err := checkFileExistance(requestID, "query.json")
+/* [Function] Create query model (dMES 전용) */
+func CreateQuery(requestID string) (*SelectQuery, error) {
+	// 요청된 requestID에 대한 데이터베이스 연결 정보 및 반출 처리 옵션 읽어오기
+	options, err := getQueryOption(requestID)
 	if err != nil {
-		return "", err
-	}
-	// 데이터베이스 연결 정보 및 반출 처리 옵션 읽어오기
-	options, err := getOptionFile(optionfilePath)
-	if err != nil {
-		return "", err
+		return nil, err
 	}
 	
-	// 쿼리문 생성을 위한 데이터베이스 및 테이블, 속성 정보 추출
-	conn := options["conn"].(map[string]interface{})
-	attributes := options["attributes"].(map[string]interface{})
-	// 기본 데이터베이스 정보와 동의 내역 데이터베이스 정보
-	baseTable := conn["database"].(string) + "." + conn["table"].(string)
-	consentTable := ""
-	// 반출할 속성 추출 및 조건 구문 생성
-	attributesToExtract := make([]string, 0)
-	conditionQuery := ""
+	// 쿼리 생성을 위한 데이터베이스 및 테이블, 속성 정보 추출
+	conn, ok := options["conn"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Invalid query option (conn)")
+	}
+	attributes, ok := options["attributes"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Invalid query option (attributes)")
+	}
+	// 기본 테이블 정보
+	query := new(SelectQuery)
+	query.From = Table{Schema: getConnValue(conn, "database"), Name: getConnValue(conn, "table")}
+	if quote, _ := conn["quoteIdentifiers"].(bool); quote {
+		query.Quote = QuoteAlways
+	}
+	// 분할 쿼리를 위한 키 컬럼 (기본값은 동의 내역 테이블과 연결하는 PROFILES_ID)
+	query.Key = Column{Table: query.From, Name: "PROFILES_ID"}
+	if keyColumn := getConnValue(conn, "keyColumn"); keyColumn != "" {
+		query.Key.Name = keyColumn
+	}
+	query.KeySampling = getConnValue(conn, "partition") == "quantile"
+	// 반출할 속성 순서 고정 (map 순회 순서는 매번 다름)
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	// 반출할 필드들과 쿼리 조건 생성
-	for key, value := range attributes {
-		detail := value.(map[string]interface{})
+	joined := make(map[Table]bool)
+	for _, key := range keys {
+		detail, ok := attributes[key].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("Invalid attribute option: " + key)
+		}
 		// Verify export check
-		if detail["isExport"].(bool) {
-			attributesToExtract = append(attributesToExtract, baseTable + "." + key)
-			// Check consent skip
-			if detail["isPii"].(bool) && !detail["isConsentSkip"].(bool) {
-				consentTable = detail["consentDatabase"].(string) + "." + detail["consentTable"].(string)
-				if conditionQuery != "" {
-					conditionQuery += " AND "
-				}
-				conditionQuery += consentTable + "." + key + "=1 AND ADD_MONTHS(TO_DATE(" + baseTable + ".LAST_ACCESSED), " + strconv.FormatFloat(detail["legalDuration"].(float64), 'f', 0, 64) + ") > NOW()"
-			}
+		if isExport, _ := detail["isExport"].(bool); !isExport {
+			continue
 		}
-	}
-	// 추출된 정보들을 이용하여 쿼리 생성
-	var buffer bytes.Buffer
-	buffer.WriteString("SELECT ")
-	for i := range attributesToExtract {
-		buffer.WriteString(attributesToExtract[i])
-		if i < len(attributesToExtract) - 1 {
-			buffer.WriteString(", ")
+		query.Columns = append(query.Columns, Column{Table: query.From, Name: key})
+		// Check consent skip
+		isPii, _ := detail["isPii"].(bool)
+		isConsentSkip, _ := detail["isConsentSkip"].(bool)
+		if !isPii || isConsentSkip {
+			continue
 		}
+		// 동의 내역 테이블 (기본 테이블과 PROFILES_ID로 연결)
+		consentTable := Table{Schema: getConnValue(detail, "consentDatabase"), Name: getConnValue(detail, "consentTable")}
+		if !joined[consentTable] {
+			joined[consentTable] = true
+			query.Joins = append(query.Joins, Join{
+				Table: consentTable,
+				Left:  Column{Table: query.From, Name: "PROFILES_ID"},
+				Right: Column{Table: consentTable, Name: "PROFILES_ID"},
+			})
+		}
+		// 동의 여부 및 법정 보유 기간 조건
+		legalDuration, _ := detail["legalDuration"].(float64)
+		query.Where = append(query.Where,
+			&Compare{Column: Column{Table: consentTable, Name: key}, Operator: "=", Value: 1},
+			&NotExpired{Column: Column{Table: query.From, Name: "LAST_ACCESSED"}, Months: int(legalDuration)},
+		)
 	}
-	buffer.WriteString(" FROM ")
-	buffer.WriteString(baseTable)
-	// Inner consent table
-	if consentTable != "" {
-		buffer.WriteString(" INNER JOIN ")
-		buffer.WriteString(consentTable)
-		buffer.WriteString(" ON ")
-		buffer.WriteString(baseTable)
-		buffer.WriteString(".PROFILES_ID=")
-		buffer.WriteString(consentTable)
-		buffer.WriteString(".PROFILES_ID")
+	// 쿼리 모델 검증 (식별자 형식 확인)
+	dsType, _ := conn["type"].(string)
+	dialect, err := GetDialect(dsType)
+	if err != nil {
+		return nil, err
 	}
-	// Condition
-	if conditionQuery != "" {
-		buffer.WriteString(" WHERE ")
-		buffer.WriteString(conditionQuery)
+	if _, err := query.Build(dialect); err != nil {
+		return nil, err
 	}
-	return buffer.String(), nil
+	return query, nil
 }
 
 /* [Internal function] 병렬 쿼리 (변환 처리 포함) */
-func parallelProcessC(stmt *sql.Stmt, dataQueue chan<- []string, nProcQuery chan<- bool, blockSize uint64, offset uint64) {
+func parallelProcess(ctx context.Context, db *sql.DB, statement *Statement, dataQueue chan<- Row, nProcQuery chan<- error) {
 	// Query
-	rows, err := stmt.Query(blockSize, offset)
-	catchError(err)
+	rows, err := db.QueryContext(ctx, statement.Syntax, statement.Args...)
+	if err != nil {
+		printLog("error", err.Error())
+		nProcQuery <- err
+		return
+	}
+	defer rows.Close()
 
 	// Get column types
 	cTypes, err := rows.ColumnTypes()
-	catchError(err)
-	// Create interface array to convert type
-	columns := make([]interface{}, len(cTypes))
-	for i := range(columns) {
-		switch cTypes[i].ScanType().String() {
-			case "int", "int8", "int16", "int32", "int64":
-				columns[i] = new(int64)
-			case "uint", "uint8", "uint16", "uint32", "uint64":
-				columns[i] = new(uint64)
-			case "float32", "float64":
-				columns[i] = new(float64)
-			case "bool":
-				columns[i] = new(bool)
-			case "driver.Decimal":
-				columns[i] = new(driver.Decimal)
-			case "string":
-				columns[i] = new(string)
-			case "time.Time":
-				columns[i] = new(time.Time)
-			default:
-				log.Println("New type:", cTypes[i].ScanType().String())
-				columns[i] = new(interface{})
-		}
-	}
-	// Get row data
-	for rows.Next() {
-		// Scan
-		rows.Scan(columns...)
-		// Convert
-		converted := make([]string, len(columns))
-		for i := range(columns) {
-			switch cTypes[i].ScanType().String() {
-				case "int", "int8", "int16", "int32", "int64":
-					converted[i] = strconv.FormatInt(*columns[i].(*int64), 10)
-				case "uint", "uint8", "uint16", "uint32", "uint64":
-					converted[i] = strconv.FormatUint(*columns[i].(*uint64), 10)
-				case "float32", "float64":
-					converted[i] = strconv.FormatFloat(*columns[i].(*float64), 'f', -6, 64)
-				case "bool":
-					converted[i] = strconv.FormatBool(*columns[i].(*bool))
-				case "driver.Decimal":
-					converted[i] = big.NewFloat(0).SetRat((*big.Rat)(columns[i].(*driver.Decimal))).String()
-				case "string":
-					converted[i] = *columns[i].(*string)
-				case "time.Time":
-					converted[i] = (columns[i].(*time.Time).Format("2006-01-02T15:04:05"))
-				default:
-					converted[i] = "*"
-			}
-		}
-		// Send data in queue(channel)
-		dataQueue <- converted
-	}
-
-	printLog("debug", "Routine exit")
-	// Return query and convert result
-	if err := rows.Err(); err != nil {
+	if err != nil {
 		printLog("error", err.Error())
-		nProcQuery <- false
-	} else {
-		nProcQuery <- true
+		nProcQuery <- err
+		return
 	}
-}
-
-/* [Internal function] 병렬 쿼리 (변환 처리 포함) */
-func parallelProcess(stmt *sql.Stmt, dataQueue chan<- []string, nProcQuery chan<- bool, blockSize uint64, offset uint64) {
-	// Query
-	rows, err := stmt.Query(blockSize, offset)
-	catchError(err)
-
-	// Get column types
-	cTypes, err := rows.Columns()
-	catchError(err)
+	// 컬럼 타입에 따라 값을 변환하는 scanner 생성
+	scanners := make([]valueScanner, len(cTypes))
 	columns := make([]interface{}, len(cTypes))
+	for i := range cTypes {
+		scanners[i].column = convertColumnType(cTypes[i])
+		columns[i] = &scanners[i]
+	}
 
 	// Get row data
 	cnt := 0
 	for rows.Next() {
-		strResult := make([]string, len(cTypes))
-		for i := range cTypes {
-			columns[i] = &strResult[i]
+		// Scan (NULL is nil)
+		if err := rows.Scan(columns...); err != nil {
+			printLog("error", err.Error())
+			nProcQuery <- err
+			return
+		}
+		row := make(Row, len(cTypes))
+		for i := range scanners {
+			row[i] = scanners[i].value
+		}
+		// 반출이 중단된 경우 대기 중인 데이터 전송을 취소하고 종료
+		select {
+			case dataQueue <- row:
+			case <-ctx.Done():
+				printLog("debug", "Routine(Query) aborted (DataCount:" + strconv.Itoa(cnt) + ")")
+				nProcQuery <- ctx.Err()
+				return
 		}
-		// Scan
-		rows.Scan(columns...)
-		dataQueue <- strResult
 		cnt++
 	}
 
@@ -267,84 +234,59 @@ func parallelProcess(stmt *sql.Stmt, dataQueue chan<- []string, nProcQuery chan<
 	// Return query and convert result
 	if err := rows.Err(); err != nil {
 		printLog("error", err.Error())
-		nProcQuery <- false
+		nProcQuery <- err
 	} else {
-		nProcQuery <- true
+		nProcQuery <- nil
 	}
 }
 
-/* [Internal function] Check file existance */
-func checkFileExistance(requestID string, filename string) (string, error) {
-	// 현재 작업 경로 추출
-	workspace, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	// 파일 경로 생성
-	filePath := path.Join(workspace, "./resources/processed", requestID, filename)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return "", err
-	} else {
-		return filePath, nil
-	}
-}
-
-/* [Internal function] Get file */
-func getOptionFile(filePath string) (map[string]interface{}, error) {
-	// 파일 열기
-	file, err := os.Open(filePath)
-	if err != nil {
+/* [Internal function] Get query option of request (request definition in the database) */
+func getQueryOption(requestID string) (map[string]interface{}, error) {
+	definition, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		return nil, errors.New("Request not found: " + requestID)
+	} else if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-	// 데이터 추출을 위한 Reader와 추출된 데이터를 저장하기 위한 Buffer 생성
-	reader := bufio.NewReader(file)
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-	buffer := make([]byte, fileInfo.Size())
-	// 데이터 읽기
-	reader.Read(buffer)
 	// []byte 데이터를 json 데이터로 변환
 	var data map[string]interface{}
-	json.Unmarshal(buffer, &data)
-
+	if err := json.Unmarshal(definition.Query, &data); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
 /* [Function] Get queryed result total data size */
-func GetDataSize(db *sql.DB, query string) (uint64, error) {
-	// Search index for modify query
-	subsequentIndex := strings.Index(query, "FROM") + 4;
-	// Combine strings (add count syntax)
-	var buf bytes.Buffer
-	buf.WriteString("SELECT COUNT(*) FROM")
-	buf.WriteString(query[subsequentIndex:])
-	modifiedQuery := buf.String()
-	// Execute query using modified query syntax
-	row := db.QueryRow(modifiedQuery)
-	// Get query result
-	var rawResult string
-	row.Scan(&rawResult)
-	result, err := strconv.Atoi(rawResult)
+func GetDataSize(ctx context.Context, db *sql.DB, dialect Dialect, query *SelectQuery) (uint64, error) {
+	// Derive count query from query model
+	statement, err := query.CountQuery().Build(dialect)
 	if err != nil {
 		return uint64(0), err
 	}
+	// Execute query using count query syntax
+	row := db.QueryRowContext(ctx, statement.Syntax, statement.Args...)
+	// Get query result
+	var result uint64
+	if err := row.Scan(&result); err != nil {
+		return uint64(0), err
+	}
 	// Return
-	return uint64(result), nil
+	return result, nil
 }
 
-func GetDataColumns(db *sql.DB, query string) ([]string, error) {
-	var buf bytes.Buffer
-	buf.WriteString(query)
-	buf.WriteString("LIMIT 1")
-	modifiedQuery := buf.String()
+/* [Function] Get queryed result columns */
+func GetDataColumns(ctx context.Context, db *sql.DB, dialect Dialect, query *SelectQuery) ([]string, error) {
+	// Derive header query (one row) from query model
+	statement, err := query.HeaderQuery().Build(dialect)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := db.Query(modifiedQuery)
+	rows, err := db.QueryContext(ctx, statement.Syntax, statement.Args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	headerInfo, err := rows.Columns()
 	if err != nil {
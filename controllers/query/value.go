@@ -0,0 +1,194 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	// Driver
+	"github.com/SAP/go-hdb/driver"
+)
+
+// Exact decimal value (decimal text, e.g. "-12.340")
+type Decimal string
+
+// Row of query result
+// Each value is nil (NULL) or typed value of column: int64, float64, bool, Decimal, time.Time, string
+type Row []interface{}
+
+/* [Function] Convert value to text (NULL is empty string, time is RFC 3339) */
+func ValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case Decimal:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+/* [Function] Parse timestamp text (RFC 3339 or SQL format) */
+func ParseTime(value string) (time.Time, error) {
+	layouts := []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999", "2006-01-02"}
+	var err error
+	for _, layout := range layouts {
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Scan destination that converts driver value to typed value of column
+type valueScanner struct {
+	column ColumnInfo
+	value  interface{}
+}
+
+func (s *valueScanner) Scan(src interface{}) error {
+	if src == nil {
+		s.value = nil
+		return nil
+	}
+	value, err := convertValue(s.column, src)
+	if err != nil {
+		return errors.New("Invalid value of column " + s.column.Name + ": " + err.Error())
+	}
+	s.value = value
+	return nil
+}
+
+/* [Internal function] Convert driver value (int64, float64, bool, []byte, string, time.Time, *big.Rat) to column type */
+func convertValue(column ColumnInfo, src interface{}) (interface{}, error) {
+	// 드라이버가 문자열 형태로 전달하는 값 (MySQL text protocol, SQLite 등)
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		// HANA decimal은 16 byte binary로 전달되므로 driver.Decimal로 변환
+		if column.ScanType == "driver.Decimal" {
+			decimal := new(driver.Decimal)
+			if err := decimal.Scan(v); err != nil {
+				return nil, err
+			}
+			return Decimal(ratString((*big.Rat)(decimal))), nil
+		}
+		text = string(v)
+	case string:
+		text = v
+	case *big.Rat:
+		// HANA decimal (go-hdb 1.x 이후)
+		if column.Type == VT_DECIMAL || column.ScanType == "driver.Decimal" {
+			return Decimal(ratString(v)), nil
+		}
+	}
+
+	switch column.Type {
+	case VT_INT64:
+		switch v := src.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v), nil
+			}
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case []byte, string:
+			return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		}
+	case VT_FLOAT64:
+		switch v := src.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case []byte, string:
+			return strconv.ParseFloat(strings.TrimSpace(text), 64)
+		}
+	case VT_BOOL:
+		switch v := src.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case []byte, string:
+			return strconv.ParseBool(strings.TrimSpace(text))
+		}
+	case VT_DECIMAL:
+		switch v := src.(type) {
+		case int64:
+			return Decimal(strconv.FormatInt(v, 10)), nil
+		case float64:
+			return Decimal(strconv.FormatFloat(v, 'f', -1, 64)), nil
+		case []byte, string:
+			if _, ok := new(big.Rat).SetString(text); !ok {
+				return nil, errors.New("invalid decimal " + strconv.Quote(text))
+			}
+			return Decimal(text), nil
+		}
+	case VT_TIME:
+		switch v := src.(type) {
+		case time.Time:
+			return v, nil
+		case []byte, string:
+			return ParseTime(text)
+		}
+	default:
+		// 문자열 컬럼은 드라이버가 다른 타입으로 전달한 경우에도 문자열로 변환
+		if _, ok := src.([]byte); ok {
+			return text, nil
+		}
+		return ValueString(src), nil
+	}
+	return nil, fmt.Errorf("unexpected type %T", src)
+}
+
+/* [Internal function] Convert rational number to exact decimal text (denominator is 2^a * 5^b) */
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	// 분모가 10^n의 약수이면 소수점 이하 n자리로 정확히 표현 가능
+	denom := new(big.Int).Set(r.Denom())
+	remainder := new(big.Int)
+	digits := 0
+	for _, factor := range []int64{2, 5} {
+		count := 0
+		divisor := big.NewInt(factor)
+		for {
+			quotient, _ := new(big.Int).QuoRem(denom, divisor, remainder)
+			if remainder.Sign() != 0 {
+				break
+			}
+			denom = quotient
+			count++
+		}
+		if count > digits {
+			digits = count
+		}
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return r.FloatString(34)
+	}
+	return r.FloatString(digits)
+}
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestConvertValue(t *testing.T) {
+	utc := time.Date(2026, 3, 1, 9, 30, 15, 500000000, time.UTC)
+	cases := []struct {
+		column   ColumnInfo
+		src      interface{}
+		expected interface{}
+	}{
+		// 드라이버 고유 타입
+		{ColumnInfo{Type: VT_INT64}, int64(-42), int64(-42)},
+		{ColumnInfo{Type: VT_INT64}, float64(7), int64(7)},
+		{ColumnInfo{Type: VT_INT64}, true, int64(1)},
+		{ColumnInfo{Type: VT_FLOAT64}, 1.25, 1.25},
+		{ColumnInfo{Type: VT_FLOAT64}, int64(3), 3.0},
+		{ColumnInfo{Type: VT_BOOL}, int64(0), false},
+		{ColumnInfo{Type: VT_TIME}, utc, utc},
+		{ColumnInfo{Type: VT_DECIMAL}, int64(12), Decimal("12")},
+		{ColumnInfo{Type: VT_DECIMAL, ScanType: "driver.Decimal"}, big.NewRat(-1234, 100), Decimal("-12.34")},
+		// 문자열로 전달되는 값 (MySQL text protocol, SQLite)
+		{ColumnInfo{Type: VT_INT64}, []byte(" 9223372036854775807"), int64(9223372036854775807)},
+		{ColumnInfo{Type: VT_FLOAT64}, "-0.5", -0.5},
+		{ColumnInfo{Type: VT_BOOL}, []byte("1"), true},
+		{ColumnInfo{Type: VT_DECIMAL, Precision: 30, Scale: 4}, []byte("123456789012345678901234.5678"), Decimal("123456789012345678901234.5678")},
+		{ColumnInfo{Type: VT_TIME}, "2026-03-01 09:30:15.5", utc},
+		{ColumnInfo{Type: VT_TIME}, []byte("2026-03-01T09:30:15.5Z"), utc},
+		// 문자열 컬럼은 다른 타입도 문자열로 변환
+		{ColumnInfo{Type: VT_STRING}, []byte("홍길동"), "홍길동"},
+		{ColumnInfo{Type: VT_STRING}, int64(5), "5"},
+		{ColumnInfo{Type: VT_STRING}, 2.5, "2.5"},
+	}
+	for _, c := range cases {
+		got, err := convertValue(c.column, c.src)
+		if err != nil {
+			t.Errorf("%v (%T) as type %d: %v", c.src, c.src, c.column.Type, err)
+			continue
+		}
+		if gotTime, ok := got.(time.Time); ok {
+			if !gotTime.Equal(c.expected.(time.Time)) {
+				t.Errorf("%v as time = %v", c.src, gotTime)
+			}
+		} else if got != c.expected {
+			t.Errorf("%v (%T) as type %d = %v (%T), want %v (%T)", c.src, c.src, c.column.Type, got, got, c.expected, c.expected)
+		}
+	}
+	// 변환할 수 없는 값은 문자열로 바꾸지 않고 오류
+	for _, c := range []struct {
+		column ColumnInfo
+		src    interface{}
+	}{
+		{ColumnInfo{Type: VT_INT64}, 1.5},
+		{ColumnInfo{Type: VT_INT64}, "12abc"},
+		{ColumnInfo{Type: VT_FLOAT64}, true},
+		{ColumnInfo{Type: VT_BOOL}, "yes"},
+		{ColumnInfo{Type: VT_DECIMAL}, "1,000"},
+		{ColumnInfo{Type: VT_TIME}, "yesterday"},
+		{ColumnInfo{Type: VT_TIME}, int64(0)},
+	} {
+		if got, err := convertValue(c.column, c.src); err == nil {
+			t.Errorf("%v (%T) as type %d = %v, want error", c.src, c.src, c.column.Type, got)
+		}
+	}
+}
+
+func TestValueScannerNull(t *testing.T) {
+	scanner := &valueScanner{column: ColumnInfo{Name: "AGE", Type: VT_INT64}, value: int64(1)}
+	if err := scanner.Scan(nil); err != nil || scanner.value != nil {
+		t.Fatalf("NULL = %v (%v)", scanner.value, err)
+	}
+	if err := scanner.Scan("x"); err == nil {
+		t.Fatal("invalid value is scanned")
+	}
+}
+
+func TestRatString(t *testing.T) {
+	cases := map[string]string{
+		"1234/100": "12.34",
+		"-1/8":     "-0.125",
+		"5/1":      "5",
+		"1/3":      "0.3333333333333333333333333333333333",
+		"0/1":      "0",
+	}
+	for value, expected := range cases {
+		r, _ := new(big.Rat).SetString(value)
+		if got := ratString(r); got != expected {
+			t.Errorf("%s = %s, want %s", value, got, expected)
+		}
+	}
+}
+
+func TestValueString(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 15, 0, time.FixedZone("KST", 9*3600))
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, ""},
+		{"a", "a"},
+		{int64(-3), "-3"},
+		{0.1, "0.1"},
+		{1e21, "1e+21"},
+		{false, "false"},
+		{Decimal("-0.050"), "-0.050"},
+		{at, "2026-03-01T09:30:15+09:00"},
+		{[]byte("b"), "b"},
+	}
+	for _, c := range cases {
+		if got := ValueString(c.value); got != c.expected {
+			t.Errorf("%v (%T) = %q, want %q", c.value, c.value, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/storage"
+)
+
+// Interval of removing full buckets
+const cleanInterval = 10 * time.Minute
+
+// Limits of each consumer (API key, user or client IP) of request (query.json > limits, server configuration if omitted)
+type Limits struct {
+	// Token bucket (requests per minute and burst size, 0 is unlimited)
+	RequestsPerMinute float64 `json:"requestsPerMinute"`
+	Burst             int     `json:"burst"`
+	// Maximum number of simultaneous exports (0 is unlimited)
+	ConcurrentExports int `json:"concurrentExports"`
+}
+
+// Token bucket of consumer
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// Time when the bucket is full again
+	full time.Time
+}
+
+var (
+	buckets   = make(map[string]*bucket)
+	running   = make(map[string]int)
+	limitMu   sync.Mutex
+	cleanedAt time.Time
+)
+
+/* [Function] Get limits of request */
+func LoadLimits(requestID string) (*Limits, error) {
+	config := configs.Get().Limit
+	limits := &Limits{
+		RequestsPerMinute: config.RequestsPerMinute,
+		Burst:             config.Burst,
+		ConcurrentExports: config.ConcurrentExports,
+	}
+	stored, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		// 존재하지 않는 요청은 handler에서 처리 (서버 기본값 적용)
+		return limits, nil
+	} else if err != nil {
+		return nil, err
+	}
+	definition := struct {
+		Limits *Limits `json:"limits"`
+	}{Limits: limits}
+	if err := json.Unmarshal(stored.Query, &definition); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+/* [Function] Take a token of consumer (time to wait for the next token if not allowed) */
+func (l *Limits) Allow(requestID string, consumer string, now time.Time) (bool, time.Duration) {
+	if l.RequestsPerMinute <= 0 {
+		return true, 0
+	}
+	rate := l.RequestsPerMinute / 60
+	capacity := float64(l.Burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+	limitMu.Lock()
+	defer limitMu.Unlock()
+	cleanBuckets(now)
+	id := requestID + "\x00" + consumer
+	b, ok := buckets[id]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		buckets[id] = b
+	}
+	// 경과 시간만큼 토큰 충전
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.last = now
+	}
+	allowed := b.tokens >= 1
+	var wait time.Duration
+	if allowed {
+		b.tokens--
+	} else {
+		wait = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	b.full = now.Add(time.Duration((capacity - b.tokens) / rate * float64(time.Second)))
+	return allowed, wait
+}
+
+/* [Function] Reserve an export slot of consumer (jobs: queued and running background exports of consumer, release after the export finishes) */
+func (l *Limits) Acquire(requestID string, consumer string, jobs int) (func(), bool) {
+	if l.ConcurrentExports <= 0 {
+		return func() {}, true
+	}
+	id := requestID + "\x00" + consumer
+	limitMu.Lock()
+	defer limitMu.Unlock()
+	if running[id]+jobs >= l.ConcurrentExports {
+		return nil, false
+	}
+	running[id]++
+	return func() {
+		limitMu.Lock()
+		defer limitMu.Unlock()
+		if running[id]--; running[id] <= 0 {
+			delete(running, id)
+		}
+	}, true
+}
+
+/* [Internal function] Remove buckets that are full again (same as a new bucket) */
+func cleanBuckets(now time.Time) {
+	if now.Sub(cleanedAt) < cleanInterval {
+		return
+	}
+	cleanedAt = now
+	for id, b := range buckets {
+		if now.After(b.full) {
+			delete(buckets, id)
+		}
+	}
+}
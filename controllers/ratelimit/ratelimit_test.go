@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+func TestMain(m *testing.M) {
+	// 요청 정의는 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-ratelimit")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestAllow(t *testing.T) {
+	limits := &Limits{RequestsPerMinute: 60, Burst: 3}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 처음에는 burst만큼 허용
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limits.Allow("ratelimit-allow", "key=a", now); !allowed {
+			t.Fatalf("request %d is not allowed", i)
+		}
+	}
+	allowed, wait := limits.Allow("ratelimit-allow", "key=a", now)
+	if allowed || wait != time.Second {
+		t.Fatalf("request over burst: allowed = %v, wait = %v", allowed, wait)
+	}
+	// 다른 요청자와 다른 요청은 별도의 bucket
+	if allowed, _ := limits.Allow("ratelimit-allow", "key=b", now); !allowed {
+		t.Fatal("other consumer is limited")
+	}
+	if allowed, _ := limits.Allow("ratelimit-other", "key=a", now); !allowed {
+		t.Fatal("other request is limited")
+	}
+	// 초당 1개씩 충전
+	allowed, wait = limits.Allow("ratelimit-allow", "key=a", now.Add(500*time.Millisecond))
+	if allowed || wait != 500*time.Millisecond {
+		t.Fatalf("after 0.5s: allowed = %v, wait = %v", allowed, wait)
+	}
+	if allowed, _ := limits.Allow("ratelimit-allow", "key=a", now.Add(time.Second)); !allowed {
+		t.Fatal("refilled token is not allowed")
+	}
+	if allowed, _ := limits.Allow("ratelimit-allow", "key=a", now.Add(time.Second)); allowed {
+		t.Fatal("token is used twice")
+	}
+	// 오래 지나도 burst 이상 충전되지 않음
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limits.Allow("ratelimit-allow", "key=a", later); !allowed {
+			t.Fatalf("request %d after an hour is not allowed", i)
+		}
+	}
+	if allowed, _ := limits.Allow("ratelimit-allow", "key=a", later); allowed {
+		t.Fatal("bucket is filled over burst")
+	}
+	// 제한 없음
+	unlimited := &Limits{}
+	for i := 0; i < 100; i++ {
+		if allowed, wait := unlimited.Allow("ratelimit-allow", "key=a", now); !allowed || wait != 0 {
+			t.Fatal("unlimited request is limited")
+		}
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	limits := &Limits{ConcurrentExports: 2}
+	release1, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.1", 0)
+	if !ok {
+		t.Fatal("first export is not allowed")
+	}
+	release2, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.1", 0)
+	if !ok {
+		t.Fatal("second export is not allowed")
+	}
+	if _, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.1", 0); ok {
+		t.Fatal("third export is allowed")
+	}
+	if release, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.2", 0); !ok {
+		t.Fatal("export of other consumer is not allowed")
+	} else {
+		release()
+	}
+	// 반출이 끝나면 다시 허용
+	release1()
+	release3, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.1", 0)
+	if !ok {
+		t.Fatal("export after release is not allowed")
+	}
+	// 대기 및 실행 중인 작업도 동시 반출 수에 포함
+	if _, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.2", 2); ok {
+		t.Fatal("export with two jobs is allowed")
+	}
+	if release, ok := limits.Acquire("ratelimit-acquire", "ip=127.0.0.2", 1); !ok {
+		t.Fatal("export with one job is not allowed")
+	} else {
+		release()
+	}
+	release2()
+	release3()
+	limitMu.Lock()
+	remaining := len(running)
+	limitMu.Unlock()
+	if remaining != 0 {
+		t.Fatalf("running exports = %d after release", remaining)
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	query := `{ "limits": { "requestsPerMinute": 6, "concurrentExports": 1 } }`
+	if err := storage.SaveDefinition("ratelimit-load", json.RawMessage(query), nil); err != nil {
+		t.Fatal(err)
+	}
+	limits, err := LoadLimits("ratelimit-load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 생략한 항목은 서버 설정 (기본값 burst 5)
+	if limits.RequestsPerMinute != 6 || limits.Burst != 5 || limits.ConcurrentExports != 1 {
+		t.Fatalf("limits = %+v", limits)
+	}
+	limits, err = LoadLimits("ratelimit-missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.RequestsPerMinute != 30 || limits.Burst != 5 || limits.ConcurrentExports != 2 {
+		t.Fatalf("default limits = %+v", limits)
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const usageText = `Usage:
+  db migrate
+  db import [-overwrite] [-dir DIR] [access log files...]`
+
+/* [Function] Manage database (db migrate / import) */
+func Command(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usageText)
+	}
+	flags := flag.NewFlagSet("db "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+	switch args[0] {
+	case "migrate":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if _, err := Open(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Database is up to date (version "+strconv.Itoa(migrations[len(migrations)-1].Version)+")")
+	case "import":
+		overwrite := flags.Bool("overwrite", false, "replace requests that already exist in the database")
+		dir := flags.String("dir", DEFAULT_DEFINITION_DIR, "directory of request definitions")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		logFiles := flags.Args()
+		if len(logFiles) == 0 {
+			logFiles = []string{DEFAULT_ACCESS_LOG}
+		}
+		result := new(ImportResult)
+		if err := ImportDefinitions(*dir, *overwrite, result); err != nil {
+			return err
+		}
+		for _, file := range logFiles {
+			if err := ImportAccessLog(file, result); err != nil {
+				return errors.New(file + ": " + err.Error())
+			}
+		}
+		fmt.Fprintln(out, "Requests: "+strconv.Itoa(result.Requests))
+		for _, skipped := range result.SkippedRequests {
+			fmt.Fprintln(out, "  skipped "+skipped)
+		}
+		fmt.Fprintln(out, "Events:   "+strconv.Itoa(result.Events))
+		if result.SkippedLines > 0 {
+			fmt.Fprintln(out, "  skipped "+strconv.Itoa(result.SkippedLines)+" invalid lines")
+		}
+	default:
+		return errors.New(usageText)
+	}
+	return nil
+}
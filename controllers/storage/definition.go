@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Request definition (query and anonymization options, stored as given)
+type Definition struct {
+	RequestID string
+	Query     json.RawMessage
+	// Anonymization options (nil if not defined)
+	Options   json.RawMessage
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+/* [Function] Get definition of request (ErrNotFound if the request does not exist) */
+func LoadDefinition(requestID string) (*Definition, error) {
+	conn, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	definition := &Definition{RequestID: requestID}
+	var query string
+	var options sql.NullString
+	err = conn.QueryRow(rebind(`SELECT query, options, created_at, updated_at FROM requests WHERE id = ?`), requestID).Scan(&query, &options, &definition.CreatedAt, &definition.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	definition.Query = json.RawMessage(query)
+	if options.Valid {
+		definition.Options = json.RawMessage(options.String)
+	}
+	return definition, nil
+}
+
+/* [Function] Create or update definition of request (nil query or options keeps the stored one) */
+func SaveDefinition(requestID string, query json.RawMessage, options json.RawMessage) error {
+	conn, err := Open()
+	if err != nil {
+		return err
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	var exists int
+	err = tx.QueryRow(rebind(`SELECT 1 FROM requests WHERE id = ?`), requestID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		// 새로운 요청은 query가 필요
+		if query == nil {
+			return ErrNotFound
+		}
+		if _, err := tx.Exec(rebind(`INSERT INTO requests (id, query, options, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`), requestID, string(query), nullString(options), now, now); err != nil {
+			return err
+		}
+		return tx.Commit()
+	} else if err != nil {
+		return err
+	}
+	if query != nil {
+		if _, err := tx.Exec(rebind(`UPDATE requests SET query = ?, updated_at = ? WHERE id = ?`), string(query), now, requestID); err != nil {
+			return err
+		}
+	}
+	if options != nil {
+		if _, err := tx.Exec(rebind(`UPDATE requests SET options = ?, updated_at = ? WHERE id = ?`), string(options), now, requestID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+/* [Function] IDs of defined requests */
+func RequestIDs() ([]string, error) {
+	conn, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(`SELECT id FROM requests ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+/* [Internal function] NULL for undefined JSON */
+func nullString(content json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(content), Valid: content != nil}
+}
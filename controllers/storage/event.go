@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"time"
+)
+
+// Event of request (export attempt, success, failure, denied or limited request, update of definition)
+type Event struct {
+	Time      time.Time
+	Event     string
+	RequestID string
+	// API key or user (key=<keyID>, user=<subject>)
+	Identity string
+	ClientIP string
+	ExportID string
+	Rows     uint64
+	Detail   string
+}
+
+/* [Function] Add event of request */
+func AddEvent(event *Event) error {
+	conn, err := Open()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(rebind(`INSERT INTO export_events (occurred_at, event, request_id, identity, client_ip, export_id, row_count, detail) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		event.Time.UTC(), event.Event, event.RequestID, event.Identity, event.ClientIP, event.ExportID, int64(event.Rows), event.Detail)
+	return err
+}
+
+/* [Function] Number of events of each request (request ID > event > count) */
+func CountEvents() (map[string]map[string]int, error) {
+	conn, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(`SELECT request_id, event, COUNT(*) FROM export_events GROUP BY request_id, event`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var requestID, event string
+		var count int
+		if err := rows.Scan(&requestID, &event, &count); err != nil {
+			return nil, err
+		}
+		if counts[requestID] == nil {
+			counts[requestID] = make(map[string]int)
+		}
+		counts[requestID][event] = count
+	}
+	return counts, rows.Err()
+}
+
+/* [Function] Number of events of request (e.g. successful exports) */
+func CountEvent(requestID string, event string) (int, error) {
+	conn, err := Open()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = conn.QueryRow(rebind(`SELECT COUNT(*) FROM export_events WHERE request_id = ? AND event = ?`), requestID, event).Scan(&count)
+	return count, err
+}
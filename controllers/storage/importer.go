@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Files of the previous version (request directories and access log)
+const (
+	DEFAULT_DEFINITION_DIR = "./resources/processed"
+	DEFAULT_ACCESS_LOG     = "./resources/logs/access.log"
+)
+
+// Result of import
+type ImportResult struct {
+	Requests int
+	// Requests already in the database or without query.json
+	SkippedRequests []string
+	Events          int
+	// Lines of access logs that are not events
+	SkippedLines int
+}
+
+/* [Function] Import files on the first start (nil result if the database already has requests) */
+func ImportIfEmpty(dir string, logFiles []string) (*ImportResult, error) {
+	conn, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	var count int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM requests`).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, nil
+	}
+	result := new(ImportResult)
+	if err := ImportDefinitions(dir, false, result); err != nil {
+		return nil, err
+	}
+	for _, file := range logFiles {
+		if err := ImportAccessLog(file, result); err != nil {
+			return nil, errors.New(file + ": " + err.Error())
+		}
+	}
+	return result, nil
+}
+
+/* [Function] Import request directories (query.json, options.json) into the database (existing requests are kept unless overwrite) */
+func ImportDefinitions(dir string, overwrite bool, result *ImportResult) error {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		requestID := entry.Name()
+		query, err := readJSON(path.Join(dir, requestID, "query.json"))
+		if err != nil {
+			return err
+		}
+		if query == nil {
+			result.SkippedRequests = append(result.SkippedRequests, requestID+" (no query.json)")
+			continue
+		}
+		options, err := readJSON(path.Join(dir, requestID, "options.json"))
+		if err != nil {
+			return err
+		}
+		if _, err := LoadDefinition(requestID); err == nil && !overwrite {
+			result.SkippedRequests = append(result.SkippedRequests, requestID+" (already imported)")
+			continue
+		} else if err != nil && err != ErrNotFound {
+			return err
+		}
+		if err := SaveDefinition(requestID, query, options); err != nil {
+			return err
+		}
+		result.Requests++
+	}
+	return nil
+}
+
+/* [Function] Import events of access log (<time> [<event>] <requestID> [identity] [detail]), lines imported before are skipped */
+func ImportAccessLog(file string, result *ImportResult) error {
+	conn, err := Open()
+	if err != nil {
+		return err
+	}
+	source, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer in.Close()
+	var imported int64
+	err = conn.QueryRow(rebind(`SELECT line_count FROM imports WHERE source = ?`), source).Scan(&imported)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	insert, err := tx.Prepare(rebind(`INSERT INTO export_events (occurred_at, event, request_id, identity, detail) VALUES (?, ?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	var lines int64
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines++
+		if lines <= imported {
+			continue
+		}
+		event, ok := parseAccessLine(scanner.Text())
+		if !ok {
+			result.SkippedLines++
+			continue
+		}
+		if _, err := insert.Exec(event.Time.UTC(), event.Event, event.RequestID, event.Identity, event.Detail); err != nil {
+			return err
+		}
+		result.Events++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if lines > imported {
+		if _, err := tx.Exec(rebind(`INSERT INTO imports (source, line_count, imported_at) VALUES (?, ?, ?) ON CONFLICT (source) DO UPDATE SET line_count = excluded.line_count, imported_at = excluded.imported_at`), source, lines, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+/* [Internal function] Parse line of access log (identity is omitted in old logs) */
+func parseAccessLine(line string) (*Event, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "[") || !strings.HasSuffix(fields[1], "]") {
+		return nil, false
+	}
+	// 로그 시간은 서버의 지역 시간
+	occurredAt, err := time.ParseInLocation("2006-01-02T15:04:05", fields[0], time.Local)
+	if err != nil {
+		return nil, false
+	}
+	event := &Event{Time: occurredAt, Event: strings.Trim(fields[1], "[]"), RequestID: fields[2]}
+	if event.Event == "" {
+		return nil, false
+	}
+	rest := fields[3:]
+	if len(rest) > 0 && (strings.HasPrefix(rest[0], "key=") || strings.HasPrefix(rest[0], "user=")) {
+		event.Identity = rest[0]
+		rest = rest[1:]
+	}
+	event.Detail = strings.Join(rest, " ")
+	return event, true
+}
+
+/* [Internal function] Read JSON file (nil if not exists) */
+func readJSON(file string) (json.RawMessage, error) {
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !json.Valid(content) {
+		return nil, errors.New("Invalid JSON: " + file)
+	}
+	return json.RawMessage(content), nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Schema migration (applied once in order of version)
+type migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// Migrations of the schema (append new migrations, never modify applied ones)
+// {{serial}}, {{timestamp}} are replaced by the types of the driver
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create requests",
+		Statements: []string{
+			// 요청 정의 (query.json, options.json의 내용을 그대로 저장)
+			`CREATE TABLE requests (
+				id VARCHAR(255) PRIMARY KEY,
+				query TEXT NOT NULL,
+				options TEXT,
+				created_at {{timestamp}} NOT NULL,
+				updated_at {{timestamp}} NOT NULL
+			)`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create export events",
+		Statements: []string{
+			// 반출 이력 (기존 access.log)
+			`CREATE TABLE export_events (
+				id {{serial}},
+				occurred_at {{timestamp}} NOT NULL,
+				event VARCHAR(32) NOT NULL,
+				request_id VARCHAR(255) NOT NULL,
+				identity VARCHAR(255) NOT NULL DEFAULT '',
+				client_ip VARCHAR(64) NOT NULL DEFAULT '',
+				export_id VARCHAR(64) NOT NULL DEFAULT '',
+				row_count BIGINT NOT NULL DEFAULT 0,
+				detail TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX export_events_request ON export_events (request_id, event)`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create imports",
+		Statements: []string{
+			// 가져온 로그 파일 (다시 가져올 때 이후에 추가된 줄만 가져옴)
+			`CREATE TABLE imports (
+				source VARCHAR(1024) PRIMARY KEY,
+				line_count BIGINT NOT NULL,
+				imported_at {{timestamp}} NOT NULL
+			)`,
+		},
+	},
+}
+
+/* [Internal function] Apply migrations that are not applied yet (each migration in a transaction) */
+func migrate(conn *sql.DB) error {
+	if _, err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at ` + columnType("{{timestamp}}") + ` NOT NULL
+	)`); err != nil {
+		return err
+	}
+	var current int
+	if err := conn.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		tx, err := conn.Begin()
+		if err != nil {
+			return err
+		}
+		for _, statement := range m.Statements {
+			if _, err := tx.Exec(columnType(statement)); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if _, err := tx.Exec(rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`), m.Version, m.Name, time.Now().UTC()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* [Internal function] Replace column types of the driver */
+func columnType(statement string) string {
+	if driver == DRIVER_POSTGRES {
+		return strings.NewReplacer("{{serial}}", "BIGSERIAL PRIMARY KEY", "{{timestamp}}", "TIMESTAMPTZ").Replace(statement)
+	}
+	return strings.NewReplacer("{{serial}}", "INTEGER PRIMARY KEY AUTOINCREMENT", "{{timestamp}}", "TIMESTAMP").Replace(statement)
+}
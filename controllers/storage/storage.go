@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	// Driver
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+const (
+	// Supported databases (storage.driver)
+	DRIVER_SQLITE   = "sqlite3"
+	DRIVER_POSTGRES = "postgres"
+)
+
+// Request or record does not exist
+var ErrNotFound = errors.New("Not found")
+
+var (
+	db     *sql.DB
+	driver string
+	dbErr  error
+	dbOnce sync.Once
+)
+
+/* [Function] Open database of the server (migrated to the latest schema, opened once) */
+func Open() (*sql.DB, error) {
+	dbOnce.Do(func() {
+		db, dbErr = open(configs.Get().Storage)
+	})
+	return db, dbErr
+}
+
+/* [Internal function] Open database and apply migrations */
+func open(config configs.StorageConfig) (*sql.DB, error) {
+	driver = strings.ToLower(config.Driver)
+	switch driver {
+	case "", "sqlite":
+		driver = DRIVER_SQLITE
+	case "postgresql":
+		driver = DRIVER_POSTGRES
+	}
+	if driver != DRIVER_SQLITE && driver != DRIVER_POSTGRES {
+		return nil, errors.New("Unsupported storage driver: " + config.Driver)
+	}
+	if driver == DRIVER_SQLITE {
+		// 데이터베이스 파일의 디렉토리 생성
+		file := strings.TrimPrefix(strings.SplitN(config.DSN, "?", 2)[0], "file:")
+		if file != "" && file != ":memory:" {
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return nil, err
+			}
+		}
+	}
+	conn, err := sql.Open(driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if driver == DRIVER_SQLITE {
+		// SQLite는 쓰기가 하나씩 처리되므로 연결 하나로 직렬화
+		conn.SetMaxOpenConns(1)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, errors.New("Failed to migrate database: " + err.Error())
+	}
+	return conn, nil
+}
+
+/* [Internal function] Replace ? placeholders with the placeholders of the driver ($1, $2, ... for PostgreSQL, quoted text is not changed) */
+func rebind(query string) string {
+	if driver != DRIVER_POSTGRES {
+		return query
+	}
+	var builder strings.Builder
+	index := 0
+	// 문자열('...')과 식별자("...") 안의 ?는 그대로 유지 ('' 또는 ""로 이스케이프된 따옴표는 닫고 다시 여는 것과 같음)
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			index++
+			builder.WriteString("$" + strconv.Itoa(index))
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	// Custom package
+	"dems-api-server/configs"
+)
+
+func TestMain(m *testing.M) {
+	// 데이터베이스와 가져올 파일은 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-storage")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestMigrate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db", "migrate.db")
+	conn, err := open(configs.StorageConfig{Driver: "sqlite", DSN: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// 다시 적용해도 이미 적용된 마이그레이션은 건너뜀
+	if err := migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	var count, version int
+	if err := conn.QueryRow(`SELECT COUNT(*), MAX(version) FROM schema_migrations`).Scan(&count, &version); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrations) || version != migrations[len(migrations)-1].Version {
+		t.Fatalf("schema_migrations: %d rows, version %d", count, version)
+	}
+	for _, table := range []string{"requests", "export_events", "imports"} {
+		if _, err := conn.Exec(`SELECT COUNT(*) FROM ` + table); err != nil {
+			t.Errorf("table %s: %v", table, err)
+		}
+	}
+	// 버전 순서대로 정의
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d", i, m.Version)
+		}
+	}
+	if _, err := open(configs.StorageConfig{Driver: "mysql"}); err == nil {
+		t.Error("unsupported driver is accepted")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	defer func(previous string) { driver = previous }(driver)
+	driver = DRIVER_POSTGRES
+	cases := map[string]string{
+		`SELECT * FROM requests WHERE id = ? AND query = ?`:                   `SELECT * FROM requests WHERE id = $1 AND query = $2`,
+		`SELECT '?', "a?b" FROM t WHERE a = ? AND b LIKE 'it''s ?' AND c = ?`: `SELECT '?', "a?b" FROM t WHERE a = $1 AND b LIKE 'it''s ?' AND c = $2`,
+		`UPDATE t SET a = '' WHERE b = ?`:                                     `UPDATE t SET a = '' WHERE b = $1`,
+	}
+	for query, expected := range cases {
+		if rebound := rebind(query); rebound != expected {
+			t.Errorf("%s\n got %s\nwant %s", query, rebound, expected)
+		}
+	}
+	// SQLite는 ? 그대로 사용
+	driver = DRIVER_SQLITE
+	if query := `SELECT ? FROM t`; rebind(query) != query {
+		t.Errorf("sqlite: %s", rebind(query))
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "req-1", "query.json"), `{ "conn": { "type": "sqlite" } }`)
+	writeFile(t, filepath.Join(dir, "req-1", "options.json"), `{ "NAME": { "method": "encryption" } }`)
+	writeFile(t, filepath.Join(dir, "req-2", "query.json"), `{ "conn": {} }`)
+	// query.json이 없는 디렉토리와 파일은 건너뜀
+	writeFile(t, filepath.Join(dir, "req-3", "options.json"), `{}`)
+	writeFile(t, filepath.Join(dir, "README"), "")
+	accessLog := filepath.Join(dir, "access.log")
+	writeFile(t, accessLog, "2026-01-02T10:00:00 [Success] req-1 key=0123456789abcdef\n"+
+		"2026-01-02T10:05:00 [Failed] req-1 Connection refused\n"+
+		"invalid line\n")
+
+	// 처음 시작하면 파일 가져오기
+	result, err := ImportIfEmpty(dir, []string{accessLog})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.Requests != 2 || len(result.SkippedRequests) != 1 || result.Events != 2 || result.SkippedLines != 1 {
+		t.Fatalf("first import = %+v", result)
+	}
+	definition, err := LoadDefinition("req-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(definition.Options) != `{ "NAME": { "method": "encryption" } }` {
+		t.Fatalf("options = %s", definition.Options)
+	}
+	if definition, err := LoadDefinition("req-2"); err != nil || definition.Options != nil {
+		t.Fatalf("definition without options = %+v (%v)", definition, err)
+	}
+
+	// 요청이 있으면 시작 시 가져오지 않음
+	if result, err := ImportIfEmpty(dir, []string{accessLog}); err != nil || result != nil {
+		t.Fatalf("import with requests = %+v (%v)", result, err)
+	}
+
+	// 다시 가져오면 기존 요청은 건너뛰고 추가된 로그만 가져옴
+	appendFile(t, accessLog, "2026-01-03T09:00:00 [Denied] req-2 user=alice Usage period has expired\n")
+	writeFile(t, filepath.Join(dir, "req-1", "query.json"), `{ "conn": { "type": "postgres" } }`)
+	result = new(ImportResult)
+	if err := ImportDefinitions(dir, false, result); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportAccessLog(accessLog, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Requests != 0 || len(result.SkippedRequests) != 3 || result.Events != 1 || result.SkippedLines != 0 {
+		t.Fatalf("second import = %+v", result)
+	}
+	if definition, _ := LoadDefinition("req-1"); string(definition.Query) != `{ "conn": { "type": "sqlite" } }` {
+		t.Fatalf("existing request is replaced: %s", definition.Query)
+	}
+	checkCount(t, "req-1", "Success", 1)
+	checkCount(t, "req-1", "Failed", 1)
+	checkCount(t, "req-2", "Denied", 1)
+
+	// 로그가 바뀌지 않았으면 가져올 줄 없음, -overwrite는 기존 요청 교체
+	result = new(ImportResult)
+	if err := ImportDefinitions(dir, true, result); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportAccessLog(accessLog, result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Requests != 2 || result.Events != 0 {
+		t.Fatalf("overwrite import = %+v", result)
+	}
+	if definition, _ := LoadDefinition("req-1"); string(definition.Query) != `{ "conn": { "type": "postgres" } }` {
+		t.Fatalf("request is not replaced: %s", definition.Query)
+	}
+	checkCount(t, "req-1", "Success", 1)
+
+	var events struct{ identity, detail string }
+	conn, _ := Open()
+	err = conn.QueryRow(`SELECT identity, detail FROM export_events WHERE event = 'Denied'`).Scan(&events.identity, &events.detail)
+	if err != nil || events.identity != "user=alice" || events.detail != "Usage period has expired" {
+		t.Fatalf("denied event = %+v (%v)", events, err)
+	}
+}
+
+func checkCount(t *testing.T, requestID string, event string, expected int) {
+	count, err := CountEvent(requestID, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != expected {
+		t.Errorf("%s %s events = %d, want %d", requestID, event, count, expected)
+	}
+}
+
+func writeFile(t *testing.T, file string, content string) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, file string, content string) {
+	out, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := out.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
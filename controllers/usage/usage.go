@@ -0,0 +1,152 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+// Usage policy of request (query.json > usage, no limit if omitted)
+type Policy struct {
+	// Usage period (date "2006-01-02" or RFC 3339, validUntil date includes the whole day)
+	ValidFrom  string `json:"validFrom"`
+	ValidUntil string `json:"validUntil"`
+	// Maximum number of successful exports
+	MaxExports int `json:"maxExports"`
+	// Maximum number of rows per export
+	MaxRows uint64 `json:"maxRows"`
+}
+
+// Export denied by usage policy (403)
+type DeniedError struct {
+	Reason string
+}
+
+var (
+	// Exports in progress (counted in the quota until they finish)
+	inFlight   = make(map[string]int)
+	inFlightMu sync.Mutex
+)
+
+func (e *DeniedError) Error() string {
+	return e.Reason
+}
+
+/* [Function] Get usage policy of request */
+func LoadPolicy(requestID string) (*Policy, error) {
+	policy := new(Policy)
+	stored, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		return nil, errors.New("Request not found: " + requestID)
+	} else if err != nil {
+		return nil, err
+	}
+	definition := struct {
+		Usage *Policy `json:"usage"`
+	}{Usage: policy}
+	if err := json.Unmarshal(stored.Query, &definition); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+/* [Function] Check usage period and quota (without reserving an export) */
+func (p *Policy) Check(requestID string, now time.Time) error {
+	if err := p.CheckPeriod(now); err != nil {
+		return err
+	}
+	if p.MaxExports <= 0 {
+		return nil
+	}
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	return p.checkQuota(requestID)
+}
+
+/* [Function] Reserve an export within usage period and quota (release after the result is logged) */
+func (p *Policy) Acquire(requestID string, now time.Time) (func(), error) {
+	if err := p.CheckPeriod(now); err != nil {
+		return nil, err
+	}
+	if p.MaxExports <= 0 {
+		return func() {}, nil
+	}
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if err := p.checkQuota(requestID); err != nil {
+		return nil, err
+	}
+	inFlight[requestID]++
+	return func() {
+		inFlightMu.Lock()
+		defer inFlightMu.Unlock()
+		if inFlight[requestID]--; inFlight[requestID] <= 0 {
+			delete(inFlight, requestID)
+		}
+	}, nil
+}
+
+/* [Function] Check number of rows of export */
+func (p *Policy) CheckRows(rows uint64) error {
+	if p.MaxRows > 0 && rows > p.MaxRows {
+		return &DeniedError{Reason: "Export exceeds the maximum number of rows (" + strconv.FormatUint(rows, 10) + " > " + strconv.FormatUint(p.MaxRows, 10) + ")"}
+	}
+	return nil
+}
+
+/* [Function] Check usage period (also checked when a stored export is downloaded) */
+func (p *Policy) CheckPeriod(now time.Time) error {
+	if p.ValidFrom != "" {
+		from, _, err := parseDate(p.ValidFrom)
+		if err != nil {
+			return err
+		}
+		if now.Before(from) {
+			return &DeniedError{Reason: "Usage period has not started (validFrom: " + p.ValidFrom + ")"}
+		}
+	}
+	if p.ValidUntil != "" {
+		until, dateOnly, err := parseDate(p.ValidUntil)
+		if err != nil {
+			return err
+		}
+		// 날짜만 지정된 경우 해당 날짜까지 활용 가능
+		if dateOnly {
+			until = until.AddDate(0, 0, 1)
+		}
+		if !now.Before(until) {
+			return &DeniedError{Reason: "Usage period has expired (validUntil: " + p.ValidUntil + ")"}
+		}
+	}
+	return nil
+}
+
+/* [Internal function] Check export quota (successful exports and exports in progress) */
+func (p *Policy) checkQuota(requestID string) error {
+	count, err := countExports(requestID)
+	if err != nil {
+		return err
+	}
+	if count+inFlight[requestID] >= p.MaxExports {
+		return &DeniedError{Reason: "Export quota exceeded (" + strconv.Itoa(count) + "/" + strconv.Itoa(p.MaxExports) + ")"}
+	}
+	return nil
+}
+
+/* [Internal function] Parse date (date only is local midnight) */
+func parseDate(value string) (time.Time, bool, error) {
+	if parsed, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return parsed, true, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	return parsed, false, err
+}
+
+/* [Internal function] Number of successful exports of request (export events) */
+func countExports(requestID string) (int, error) {
+	return storage.CountEvent(requestID, "Success")
+}
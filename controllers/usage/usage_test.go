@@ -0,0 +1,151 @@
+package usage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"time"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+func TestMain(m *testing.M) {
+	// 요청 정의와 반출 이력은 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-usage")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestCheckPeriod(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local)
+	cases := []struct {
+		policy  Policy
+		allowed bool
+	}{
+		{Policy{}, true},
+		{Policy{ValidFrom: "2026-06-15"}, true},
+		{Policy{ValidFrom: "2026-06-16"}, false},
+		// 날짜만 지정한 validUntil은 해당 날짜까지 포함
+		{Policy{ValidUntil: "2026-06-15"}, true},
+		{Policy{ValidUntil: "2026-06-14"}, false},
+		{Policy{ValidFrom: "2026-01-01", ValidUntil: "2026-12-31"}, true},
+		{Policy{ValidUntil: now.Format(time.RFC3339)}, false},
+		{Policy{ValidUntil: now.Add(time.Second).Format(time.RFC3339)}, true},
+		{Policy{ValidFrom: now.Add(time.Second).Format(time.RFC3339)}, false},
+	}
+	for _, c := range cases {
+		err := c.policy.CheckPeriod(now)
+		if c.allowed && err != nil {
+			t.Errorf("%+v: %v", c.policy, err)
+		} else if !c.allowed {
+			if _, ok := err.(*DeniedError); !ok {
+				t.Errorf("%+v: error = %v, want denied", c.policy, err)
+			}
+		}
+	}
+	// 형식이 잘못된 날짜는 거부 사유가 아닌 오류
+	err := (&Policy{ValidUntil: "2026/12/31"}).CheckPeriod(now)
+	if _, denied := err.(*DeniedError); err == nil || denied {
+		t.Errorf("invalid date: %v", err)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	requestID := "usage-quota"
+	query := `{ "usage": { "maxExports": 2, "maxRows": 100 } }`
+	if err := storage.SaveDefinition(requestID, json.RawMessage(query), nil); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicy(requestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.MaxExports != 2 || policy.MaxRows != 100 {
+		t.Fatalf("policy = %+v", policy)
+	}
+	now := time.Now()
+
+	// 진행 중인 반출도 횟수에 포함
+	release1, err := policy.Acquire(requestID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := policy.Acquire(requestID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := policy.Acquire(requestID, now); !isDenied(err) {
+		t.Fatalf("third in-flight export: %v", err)
+	}
+	if err := policy.Check(requestID, now); !isDenied(err) {
+		t.Fatalf("check with two in-flight exports: %v", err)
+	}
+	// 실패한 반출은 완료 후 횟수에서 제외
+	release2()
+	if err := policy.Check(requestID, now); err != nil {
+		t.Fatal(err)
+	}
+	// 성공한 반출은 반출 이력으로 계산
+	if err := storage.AddEvent(&storage.Event{Time: now, Event: "Success", RequestID: requestID}); err != nil {
+		t.Fatal(err)
+	}
+	release1()
+	if err := policy.Check(requestID, now); err != nil {
+		t.Fatal(err)
+	}
+	release, err := policy.Acquire(requestID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddEvent(&storage.Event{Time: now, Event: "Success", RequestID: requestID}); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, err := policy.Acquire(requestID, now); !isDenied(err) {
+		t.Fatalf("export after quota: %v", err)
+	}
+	// 다른 요청에는 영향 없음
+	if _, err := (&Policy{MaxExports: 1}).Acquire("usage-other", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := policy.CheckRows(100); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.CheckRows(101); !isDenied(err) {
+		t.Fatalf("rows over limit: %v", err)
+	}
+}
+
+func TestLoadPolicyWithoutUsage(t *testing.T) {
+	if err := storage.SaveDefinition("usage-none", json.RawMessage(`{ "conn": {} }`), nil); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicy("usage-none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, err := policy.Acquire("usage-none", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, err := LoadPolicy("usage-missing"); err == nil {
+		t.Fatal("policy of missing request is loaded")
+	}
+}
+
+/* [Internal function] Check if export is denied by usage policy */
+func isDenied(err error) bool {
+	_, ok := err.(*DeniedError)
+	return ok
+}
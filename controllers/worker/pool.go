@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"context"
+	"sync"
+
+	// Custom package
+	"dems-api-server/configs"
+)
+
+// Worker pool (limits the number of concurrent tasks)
+type Pool struct {
+	slots chan struct{}
+}
+
+// Task group of one export (limited by both group size and pool size)
+type Group struct {
+	ctx   context.Context
+	pool  *Pool
+	slots chan struct{}
+}
+
+var (
+	queryPool *Pool
+	anonyPool *Pool
+	poolOnce  sync.Once
+)
+
+/* [Function] Create worker pool */
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{slots: make(chan struct{}, size)}
+}
+
+/* [Function] Server-wide pool for queries (database connections) */
+func QueryPool() *Pool {
+	poolOnce.Do(initPools)
+	return queryPool
+}
+
+/* [Function] Server-wide pool for anonymization workers */
+func AnonyPool() *Pool {
+	poolOnce.Do(initPools)
+	return anonyPool
+}
+
+/* [Function] Acquire slot (blocking) */
+func (p *Pool) Acquire() {
+	p.slots <- struct{}{}
+}
+
+/* [Function] Acquire slot (blocking until ctx is done) */
+func (p *Pool) AcquireContext(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/* [Function] Release slot */
+func (p *Pool) Release() {
+	<-p.slots
+}
+
+/* [Function] Acquire up to n slots without blocking and return the acquired count */
+func (p *Pool) TryAcquire(n int) int {
+	acquired := 0
+	for ; acquired < n; acquired++ {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			return acquired
+		}
+	}
+	return acquired
+}
+
+/* [Function] Release n slots */
+func (p *Pool) ReleaseN(n int) {
+	for i := 0; i < n; i++ {
+		p.Release()
+	}
+}
+
+/* [Function] Create task group for one export (waiting tasks are dropped when ctx is done) */
+func (p *Pool) NewGroup(ctx context.Context, limit int) *Group {
+	return &Group{ctx: ctx, pool: p, slots: NewPool(limit).slots}
+}
+
+/* [Function] Run task when both group and pool slots are available */
+func (g *Group) Go(task func()) {
+	go func() {
+		// 요청 단위 제한을 먼저 확인하여 대기 중인 작업이 전역 슬롯을 차지하지 않도록 처리
+		select {
+		case g.slots <- struct{}{}:
+		case <-g.ctx.Done():
+			return
+		}
+		defer func() { <-g.slots }()
+		select {
+		case g.pool.slots <- struct{}{}:
+		case <-g.ctx.Done():
+			return
+		}
+		defer g.pool.Release()
+		task()
+	}()
+}
+
+/* [Internal function] Create server-wide pools from configuration */
+func initPools() {
+	config := configs.Get().Worker
+	queryPool = NewPool(config.QueryGlobal)
+	anonyPool = NewPool(config.AnonyGlobal)
+}
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcquireContext(t *testing.T) {
+	pool := NewPool(1)
+	if err := pool.AcquireContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 슬롯이 없으면 취소될 때까지 대기
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.AcquireContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v", err)
+	}
+	if acquired := pool.TryAcquire(1); acquired != 0 {
+		t.Fatalf("acquired %d slots of a full pool", acquired)
+	}
+	pool.Release()
+	if err := pool.AcquireContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/allowlist"
+)
+
+/* [Function] Middleware to check allowed networks and client certificates of request (before connecting to the data source) */
+func RestrictAccess(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestID := ctx.Param("requestID")
+		if err := checkAccess(ctx, requestID); err != nil {
+			return failAccess(ctx, requestID, err)
+		}
+		return next(ctx)
+	}
+}
+
+/* [Internal function] Check access policy of request */
+func checkAccess(ctx echo.Context, requestID string) error {
+	policy, err := allowlist.LoadPolicy(requestID)
+	if err != nil {
+		return err
+	}
+	return policy.Check(clientIP(ctx), ctx.Request().TLS)
+}
+
+/* [Internal function] Outputs 403 error if access is denied by the policy */
+func failAccess(ctx echo.Context, requestID string, err error) error {
+	if denied, ok := err.(*allowlist.DeniedError); ok {
+		return denyExport(ctx, requestID, denied.Reason)
+	}
+	return catchError(ctx, err)
+}
+
+/* [Internal function] Client address (proxy headers are used only if trusted) */
+func clientIP(ctx echo.Context) string {
+	if configs.Get().Server.TrustProxyHeaders {
+		return ctx.RealIP()
+	}
+	host, _, err := net.SplitHostPort(ctx.Request().RemoteAddr)
+	if err != nil {
+		return ctx.Request().RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/controllers/audit"
+	"dems-api-server/controllers/storage"
+)
+
+// Response structrue
+type ResponseAudit struct {
+	Result  bool                `json:"result" xml:"result"`
+	Message *audit.Verification `json:"message" xml:"message"`
+}
+
+/* [Function] Verify hash chain of the audit log (result is false with the first broken record) */
+func VerifyAudit(ctx echo.Context) error {
+	result, err := audit.Verify("")
+	if err != nil {
+		return catchError(ctx, err)
+	}
+	if !result.Valid {
+		printLog("error", "Audit log is broken at line " + strconv.Itoa(result.BrokenLine) + ": " + result.Reason)
+	}
+	return ctx.JSON(http.StatusOK, &ResponseAudit{Result: result.Valid, Message: result})
+}
+
+/* [Internal function] Record event of request in the audit log and the export history (database) */
+func recordEvent(ctx echo.Context, record audit.Record) error {
+	if record.Identity == "" {
+		record.Identity = accessIdentity(ctx)
+	}
+	record.ClientIP = clientIP(ctx)
+	if record.ExportID == "" {
+		record.ExportID = ctx.Response().Header().Get("X-Export-ID")
+	}
+	if err := audit.Append(&record); err != nil {
+		printLog("error", "Failed to write audit log (" + record.RequestID + "): " + err.Error())
+		return err
+	}
+	event := &storage.Event{
+		Time: time.Now(),
+		Event: record.Event,
+		RequestID: record.RequestID,
+		Identity: record.Identity,
+		ClientIP: record.ClientIP,
+		ExportID: record.ExportID,
+		Rows: record.Rows,
+		Detail: record.Detail,
+	}
+	if err := storage.AddEvent(event); err != nil {
+		printLog("error", "Failed to record event (" + record.RequestID + "): " + err.Error())
+		return err
+	}
+	return nil
+}
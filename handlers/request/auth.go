@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/apikey"
+	"dems-api-server/controllers/audit"
+	"dems-api-server/controllers/oidc"
+	"dems-api-server/controllers/storage"
+)
+
+/* [Function] Middleware to authorize access token (staff with the role) or API key (exporter of its own request) */
+func Authorize(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			auth := configs.Get().Auth
+			if !auth.APIKey && !oidc.Enabled() {
+				return next(ctx)
+			}
+			requestID := ctx.Param("requestID")
+			// Access token of staff (Authorization: Bearer <token>)
+			if token, ok := bearerToken(ctx); ok && oidc.Enabled() {
+				identity, err := oidc.Verify(token, time.Now())
+				if err != nil {
+					ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer error=\"invalid_token\"")
+					return unauthorized(ctx, requestID, err.Error())
+				}
+				if !identity.HasRole(role) {
+					return forbidden(ctx, requestID, "user=" + logValue(identity.Subject), "Role " + role + " is required")
+				}
+				ctx.Set(oidc.CONTEXT_KEY, identity)
+				return next(ctx)
+			}
+			// API key of request (X-API-Key)
+			if token := ctx.Request().Header.Get(apikey.HEADER); token != "" && auth.APIKey {
+				key, err := apikey.Validate(token, time.Now())
+				if err == apikey.ErrInvalidKey {
+					return unauthorized(ctx, requestID, err.Error())
+				} else if err != nil {
+					return catchError(ctx, err)
+				}
+				// API 키는 해당 요청의 반출에만 사용 가능 (요청 정의 수정 불가)
+				if role == oidc.ROLE_ADMIN {
+					return forbidden(ctx, requestID, "key=" + key.ID, "Role " + role + " is required")
+				}
+				if requestID != "" && requestID != key.RequestID {
+					return forbidden(ctx, requestID, "key=" + key.ID, "API key is not valid for this request")
+				}
+				ctx.Set(apikey.CONTEXT_KEY, key)
+				return next(ctx)
+			}
+			if oidc.Enabled() {
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+			}
+			return unauthorized(ctx, requestID, "Authentication is required")
+		}
+	}
+}
+
+/* [Internal function] Authenticated API key of the context (nil if the request is not authenticated by API key) */
+func requestKey(ctx echo.Context) *apikey.Key {
+	key, _ := ctx.Get(apikey.CONTEXT_KEY).(*apikey.Key)
+	return key
+}
+
+/* [Internal function] Authenticated user of the context (nil if the request is not authenticated by access token) */
+func requestUser(ctx echo.Context) *oidc.Identity {
+	identity, _ := ctx.Get(oidc.CONTEXT_KEY).(*oidc.Identity)
+	return identity
+}
+
+/* [Internal function] Identity recorded in the export history and the audit log (key=<keyID>, user=<subject>, or key=- if authentication is disabled) */
+func accessIdentity(ctx echo.Context) string {
+	if key := requestKey(ctx); key != nil {
+		return "key=" + key.ID
+	}
+	if user := requestUser(ctx); user != nil {
+		return "user=" + logValue(user.Subject)
+	}
+	return "key=-"
+}
+
+/* [Internal function] Bearer token of Authorization header */
+func bearerToken(ctx echo.Context) (string, bool) {
+	header := ctx.Request().Header.Get(echo.HeaderAuthorization)
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:]), true
+	}
+	return "", false
+}
+
+/* [Internal function] Value without whitespace (identity and log messages) */
+func logValue(value string) string {
+	return strings.Join(strings.Fields(value), "_")
+}
+
+/* [Internal function] Whether the request exists (rejected credentials are recorded only for existing requests) */
+func knownRequest(requestID string) bool {
+	if requestID == "" {
+		return false
+	}
+	_, err := storage.LoadDefinition(requestID)
+	return err == nil
+}
+
+/* [Internal function] Record rejected credential of existing request, then outputs 401 error */
+func unauthorized(ctx echo.Context, requestID string, reason string) error {
+	if knownRequest(requestID) {
+		recordEvent(ctx, audit.Record{Event: "Unauthorized", RequestID: requestID, Identity: "key=-", Detail: reason})
+	}
+	return catchError(ctx, &echo.HTTPError{Code: http.StatusUnauthorized, Message: reason})
+}
+
+/* [Internal function] Record credential without permission of existing request, then outputs 403 error */
+func forbidden(ctx echo.Context, requestID string, who string, reason string) error {
+	printLog("warning", "Access denied (" + who + "): " + reason)
+	if knownRequest(requestID) {
+		recordEvent(ctx, audit.Record{Event: "Unauthorized", RequestID: requestID, Identity: who, Detail: reason})
+	}
+	return catchError(ctx, &echo.HTTPError{Code: http.StatusForbidden, Message: reason})
+}
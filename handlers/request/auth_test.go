@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/controllers/oidc"
+	"dems-api-server/controllers/storage"
+)
+
+/* [Internal function] Call handler with middlewares (path parameter requestID) */
+func serve(handler echo.HandlerFunc, remoteAddr string, requestID string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/request/"+requestID, nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(req, rec)
+	ctx.SetParamNames("requestID")
+	ctx.SetParamValues(requestID)
+	handler(ctx)
+	return rec
+}
+
+func TestUnauthorizedEvents(t *testing.T) {
+	requestID := "auth-known"
+	if err := storage.SaveDefinition(requestID, json.RawMessage(`{}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	ok := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}
+	handler := Authorize(oidc.ROLE_EXPORTER)(ok)
+
+	// 존재하는 요청만 감사 로그와 반출 이력에 기록
+	for _, header := range []http.Header{nil, {"X-Api-Key": {"dems_0123456789abcdef_secret"}}} {
+		if rec := serve(handler, "192.0.2.1:1000", requestID, header); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d", rec.Code)
+		}
+		if rec := serve(handler, "192.0.2.1:1000", "auth-unknown", header); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+	if count, err := storage.CountEvent(requestID, "Unauthorized"); err != nil || count != 2 {
+		t.Fatalf("events of existing request = %d (%v)", count, err)
+	}
+	if count, err := storage.CountEvent("auth-unknown", "Unauthorized"); err != nil || count != 0 {
+		t.Fatalf("events of unknown request = %d (%v)", count, err)
+	}
+}
+
+func TestClientLimit(t *testing.T) {
+	handler := ClientLimit(Authorize(oidc.ROLE_EXPORTER)(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}))
+	// 기본값 burst 20, 인증 전에 제한하며 기록하지 않음
+	for i := 0; i < 20; i++ {
+		if rec := serve(handler, "198.51.100.1:1000", "auth-flood", nil); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("request %d: status = %d", i, rec.Code)
+		}
+	}
+	rec := serve(handler, "198.51.100.1:1000", "auth-flood", nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("status over burst = %d, Retry-After = %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	// 다른 주소는 별도로 제한
+	if rec := serve(handler, "198.51.100.2:1000", "auth-flood", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("other client: status = %d", rec.Code)
+	}
+	if count, err := storage.CountEvent("auth-flood", "Unauthorized"); err != nil || count != 0 {
+		t.Fatalf("events = %d (%v)", count, err)
+	}
+}
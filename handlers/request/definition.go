@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/controllers/audit"
+	"dems-api-server/controllers/storage"
+)
+
+// Request ID (name of the request directory of recipient keys)
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Placeholder of the source database password in responses
+const REDACTED = "********"
+// Connection info which is not returned (query.json > conn)
+var secretConnKeys = []string{"pwd", "dsn", "url"}
+
+// Request definition (query and anonymization options)
+type Definition struct {
+	Query   json.RawMessage `json:"query,omitempty" xml:"-"`
+	Options json.RawMessage `json:"options,omitempty" xml:"-"`
+}
+// Response structrue
+type ResponseDefinition struct {
+	Result bool `json:"result" xml:"result"`
+	Message *Definition `json:"message" xml:"message"`
+}
+
+/* [Function] Get request definition */
+func GetDefinition(ctx echo.Context) error {
+	requestID := ctx.Param("requestID")
+	if err := checkRequestID(requestID); err != nil {
+		return catchError(ctx, err)
+	}
+	stored, err := storage.LoadDefinition(requestID)
+	if err == storage.ErrNotFound {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Request not found: " + requestID})
+	} else if err != nil {
+		return catchError(ctx, err)
+	}
+	query, err := redactQuery(stored.Query)
+	if err != nil {
+		return catchError(ctx, err)
+	}
+	definition := &Definition{Query: query, Options: stored.Options}
+	return ctx.JSON(http.StatusOK, &ResponseDefinition{Result: true, Message: definition})
+}
+
+/* [Function] Create or replace request definition (query and/or options) */
+func UpdateDefinition(ctx echo.Context) error {
+	requestID := ctx.Param("requestID")
+	if err := checkRequestID(requestID); err != nil {
+		return catchError(ctx, err)
+	}
+	definition := new(Definition)
+	if err := json.NewDecoder(ctx.Request().Body).Decode(definition); err != nil {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusBadRequest, Message: "Invalid definition: " + err.Error()})
+	}
+	if definition.Query == nil && definition.Options == nil {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusBadRequest, Message: "query or options is required"})
+	}
+	// 각 정의는 JSON 객체
+	for _, content := range []json.RawMessage{definition.Query, definition.Options} {
+		object := make(map[string]json.RawMessage)
+		if content != nil && json.Unmarshal(content, &object) != nil {
+			return catchError(ctx, &echo.HTTPError{Code: http.StatusBadRequest, Message: "Definition must be a JSON object"})
+		}
+	}
+	// 응답에서 가린 값을 그대로 보낸 경우 저장된 값을 유지
+	if definition.Query != nil {
+		query, err := restoreQuery(requestID, definition.Query)
+		if err != nil {
+			return catchError(ctx, err)
+		}
+		definition.Query = query
+	}
+	// 새로운 요청은 query가 필요
+	err := storage.SaveDefinition(requestID, definition.Query, definition.Options)
+	if err == storage.ErrNotFound {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Request not found: " + requestID})
+	} else if err != nil {
+		return catchError(ctx, err)
+	}
+	printLog("debug", "Updated request definition (" + requestID + ")")
+	recordEvent(ctx, audit.Record{Event: "Updated", RequestID: requestID})
+	return GetDefinition(ctx)
+}
+
+/* [Internal function] Check request ID (also the name of the request directory, so it must not be a path) */
+func checkRequestID(requestID string) error {
+	if !requestIDPattern.MatchString(requestID) {
+		return &echo.HTTPError{Code: http.StatusBadRequest, Message: "Invalid request ID: " + requestID}
+	}
+	return nil
+}
+
+/* [Internal function] Replace password and DSN of the connection info with placeholder */
+func redactQuery(query json.RawMessage) (json.RawMessage, error) {
+	object, conn := splitQuery(query)
+	if conn == nil {
+		return query, nil
+	}
+	redacted := false
+	for _, key := range secretConnKeys {
+		if _, ok := conn[key]; ok {
+			conn[key], _ = json.Marshal(REDACTED)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return query, nil
+	}
+	return joinQuery(object, conn)
+}
+
+/* [Internal function] Replace placeholder of the connection info with stored value */
+func restoreQuery(requestID string, query json.RawMessage) (json.RawMessage, error) {
+	object, conn := splitQuery(query)
+	if conn == nil {
+		return query, nil
+	}
+	var storedConn map[string]json.RawMessage
+	restored := false
+	for _, key := range secretConnKeys {
+		var value string
+		if json.Unmarshal(conn[key], &value) != nil || value != REDACTED {
+			continue
+		}
+		if storedConn == nil {
+			stored, err := storage.LoadDefinition(requestID)
+			if err != nil && err != storage.ErrNotFound {
+				return nil, err
+			} else if err == nil {
+				_, storedConn = splitQuery(stored.Query)
+			}
+		}
+		storedValue, ok := storedConn[key]
+		if !ok {
+			return nil, &echo.HTTPError{Code: http.StatusBadRequest, Message: "No stored value of conn." + key}
+		}
+		conn[key] = storedValue
+		restored = true
+	}
+	if !restored {
+		return query, nil
+	}
+	return joinQuery(object, conn)
+}
+
+/* [Internal function] Split query into top-level fields and connection info (nil if conn is not an object) */
+func splitQuery(query json.RawMessage) (map[string]json.RawMessage, map[string]json.RawMessage) {
+	object := make(map[string]json.RawMessage)
+	if json.Unmarshal(query, &object) != nil {
+		return nil, nil
+	}
+	var conn map[string]json.RawMessage
+	if json.Unmarshal(object["conn"], &conn) != nil {
+		return nil, nil
+	}
+	return object, conn
+}
+
+/* [Internal function] Join connection info into query */
+func joinQuery(object map[string]json.RawMessage, conn map[string]json.RawMessage) (json.RawMessage, error) {
+	var err error
+	if object["conn"], err = json.Marshal(conn); err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
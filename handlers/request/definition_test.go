@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/controllers/storage"
+)
+
+/* [Internal function] Call definition handler and decode connection info of the response */
+func callDefinition(t *testing.T, handler echo.HandlerFunc, method string, requestID string, body string) (int, map[string]string) {
+	req := httptest.NewRequest(method, "/request/"+requestID+"/definition", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(req, rec)
+	ctx.SetParamNames("requestID")
+	ctx.SetParamValues(requestID)
+	handler(ctx)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var response struct {
+		Message struct {
+			Query struct {
+				Conn map[string]string `json:"conn"`
+			} `json:"query"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, response.Message.Query.Conn
+}
+
+/* [Internal function] Stored connection info of the request */
+func storedConn(t *testing.T, requestID string) map[string]string {
+	stored, err := storage.LoadDefinition(requestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var query struct {
+		Conn map[string]string `json:"conn"`
+	}
+	if err := json.Unmarshal(stored.Query, &query); err != nil {
+		t.Fatal(err)
+	}
+	return query.Conn
+}
+
+func TestDefinitionPassword(t *testing.T) {
+	requestID := "definition-secret"
+	query := `{ "query": { "conn": { "type": "postgres", "host": "db", "user": "dems", "pwd": "secret" }, "attributes": {} } }`
+	code, conn := callDefinition(t, UpdateDefinition, http.MethodPut, requestID, query)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	// 응답에서는 비밀번호를 가림
+	if conn["pwd"] != REDACTED || conn["user"] != "dems" {
+		t.Fatalf("conn = %v", conn)
+	}
+	if _, conn = callDefinition(t, GetDefinition, http.MethodGet, requestID, ""); conn["pwd"] != REDACTED {
+		t.Fatalf("conn = %v", conn)
+	}
+
+	// 가린 값을 다시 보내면 저장된 비밀번호를 유지
+	query = `{ "query": { "conn": { "type": "postgres", "host": "db2", "user": "dems", "pwd": "` + REDACTED + `" }, "attributes": {} } }`
+	if code, _ = callDefinition(t, UpdateDefinition, http.MethodPut, requestID, query); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if conn = storedConn(t, requestID); conn["pwd"] != "secret" || conn["host"] != "db2" {
+		t.Fatalf("stored conn = %v", conn)
+	}
+	// 새로운 비밀번호는 교체
+	query = `{ "query": { "conn": { "type": "postgres", "host": "db2", "user": "dems", "pwd": "changed" }, "attributes": {} } }`
+	if code, _ = callDefinition(t, UpdateDefinition, http.MethodPut, requestID, query); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if conn = storedConn(t, requestID); conn["pwd"] != "changed" {
+		t.Fatalf("stored conn = %v", conn)
+	}
+	// 저장된 값이 없으면 가린 값을 받지 않음
+	query = `{ "query": { "conn": { "type": "postgres", "pwd": "` + REDACTED + `" } } }`
+	if code, _ = callDefinition(t, UpdateDefinition, http.MethodPut, "definition-new", query); code != http.StatusBadRequest {
+		t.Fatalf("status = %d", code)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/controllers/allowlist"
+	"dems-api-server/controllers/artifact"
+	"dems-api-server/controllers/job"
+	"dems-api-server/controllers/storage"
+	"dems-api-server/controllers/usage"
+)
+
+// Response structrue
+type ResponseJob struct {
+	Result  bool     `json:"result" xml:"result"`
+	Message *job.Job `json:"message" xml:"message"`
+}
+
+/* [Function] Start background export of request (parameters are the same as the synchronous export) */
+func CreateJob(ctx echo.Context) error {
+	requestID := ctx.Param("requestID")
+	// Check request definition
+	if _, err := storage.LoadDefinition(requestID); err == storage.ErrNotFound {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Request not found: " + requestID})
+	} else if err != nil {
+		return catchError(ctx, err)
+	}
+	// Check usage period and export quota before queueing (checked again when the job runs)
+	policy, err := usage.LoadPolicy(requestID)
+	if err != nil {
+		return catchError(ctx, err)
+	}
+	if err := policy.Check(requestID, time.Now()); err != nil {
+		return failUsage(ctx, requestID, err)
+	}
+	keyID, subject := "", ""
+	if key := requestKey(ctx); key != nil {
+		keyID = key.ID
+	} else if user := requestUser(ctx); user != nil {
+		subject = user.Subject
+	}
+	certSubject, certCommonName := allowlist.ClientSubject(ctx.Request().TLS)
+	created, err := job.Create(&job.Job{
+		RequestID:      requestID,
+		KeyID:          keyID,
+		Subject:        subject,
+		ClientIP:       clientIP(ctx),
+		CertSubject:    certSubject,
+		CertCommonName: certCommonName,
+		Params:         ctx.QueryParams(),
+		Accept:         ctx.Request().Header.Get(echo.HeaderAccept),
+	})
+	if err != nil {
+		return catchError(ctx, err)
+	}
+	printLog("debug", "Queued export job " + created.ID + " (" + requestID + ")")
+	ctx.Response().Header().Set(echo.HeaderLocation, "/jobs/" + created.ID)
+	return ctx.JSON(http.StatusAccepted, &ResponseJob{Result: true, Message: created})
+}
+
+/* [Function] State of export job */
+func JobStatus(ctx echo.Context) error {
+	found := findJob(ctx)
+	if found == nil {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Job not found"})
+	}
+	if err := checkAccess(ctx, found.RequestID); err != nil {
+		return failAccess(ctx, found.RequestID, err)
+	}
+	return ctx.JSON(http.StatusOK, &ResponseJob{Result: true, Message: found})
+}
+
+/* [Function] Download result file of finished export job */
+func JobResult(ctx echo.Context) error {
+	found := findJob(ctx)
+	if found == nil {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Job not found"})
+	}
+	if err := checkAccess(ctx, found.RequestID); err != nil {
+		return failAccess(ctx, found.RequestID, err)
+	}
+	if found.State != job.JS_DONE {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusConflict, Message: "Job is " + found.State})
+	}
+	// Result file is the artifact of the export
+	exportArtifact, filePath, err := artifact.Get(found.RequestID, found.ExportID)
+	if os.IsNotExist(err) || err == artifact.ErrExpired {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusGone, Message: artifact.ErrExpired.Error()})
+	} else if err != nil {
+		return catchError(ctx, err)
+	}
+	if err := checkPeriod(found.RequestID); err != nil {
+		return failUsage(ctx, found.RequestID, err)
+	}
+	ctx.Response().Header().Set("X-Export-Rows", strconv.FormatUint(found.Rows, 10))
+	return serveArtifact(ctx, exportArtifact, filePath)
+}
+
+/* [Internal function] Get job of the context (jobs of other requests are not found with API key) */
+func findJob(ctx echo.Context) *job.Job {
+	found := job.Get(ctx.Param("jobID"))
+	if found == nil {
+		return nil
+	}
+	if key := requestKey(ctx); key != nil && key.RequestID != found.RequestID {
+		return nil
+	}
+	return found
+}
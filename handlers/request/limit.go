@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/audit"
+	"dems-api-server/controllers/job"
+	"dems-api-server/controllers/ratelimit"
+)
+
+// Retry-After of exceeded concurrent exports (seconds)
+const CONCURRENT_RETRY_AFTER = 10
+
+/* [Function] Middleware to limit export requests of each consumer (token bucket and simultaneous exports) */
+func RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestID := ctx.Param("requestID")
+		limits, err := ratelimit.LoadLimits(requestID)
+		if err != nil {
+			return catchError(ctx, err)
+		}
+		consumer := consumerID(ctx)
+		if ok, wait := limits.Allow(requestID, consumer, time.Now()); !ok {
+			return tooManyRequests(ctx, requestID, int(math.Ceil(wait.Seconds())), "Rate limit exceeded (" + strconv.FormatFloat(limits.RequestsPerMinute, 'f', -1, 64) + " requests per minute)")
+		}
+		// 동기 반출과 대기 및 실행 중인 백그라운드 작업을 동시 반출 수에 포함 (작업 등록 요청은 등록하는 동안 slot 사용)
+		jobs := 0
+		for _, active := range job.Active(requestID) {
+			if jobConsumer(active) == consumer {
+				jobs++
+			}
+		}
+		release, ok := limits.Acquire(requestID, consumer, jobs)
+		if !ok {
+			return tooManyRequests(ctx, requestID, CONCURRENT_RETRY_AFTER, "Too many simultaneous exports (" + strconv.Itoa(limits.ConcurrentExports) + ")")
+		}
+		defer release()
+		return next(ctx)
+	}
+}
+
+/* [Function] Middleware to limit requests of each client address before authentication (not recorded in the audit log) */
+func ClientLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		config := configs.Get().Limit
+		limits := &ratelimit.Limits{RequestsPerMinute: config.ClientRequestsPerMinute, Burst: config.ClientBurst}
+		// 요청과 관계없이 클라이언트 주소별 bucket 사용
+		if ok, wait := limits.Allow("", "ip=" + clientIP(ctx), time.Now()); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			ctx.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			return catchError(ctx, &echo.HTTPError{Code: http.StatusTooManyRequests, Message: "Too many requests from " + clientIP(ctx)})
+		}
+		return next(ctx)
+	}
+}
+
+/* [Internal function] Consumer of the request (API key, user or client IP) */
+func consumerID(ctx echo.Context) string {
+	if key := requestKey(ctx); key != nil {
+		return "key=" + key.ID
+	}
+	if user := requestUser(ctx); user != nil {
+		return "user=" + logValue(user.Subject)
+	}
+	return "ip=" + clientIP(ctx)
+}
+
+/* [Internal function] Consumer that created the job (same as consumerID of the job request) */
+func jobConsumer(created *job.Job) string {
+	if created.KeyID != "" {
+		return "key=" + created.KeyID
+	}
+	if created.Subject != "" {
+		return "user=" + logValue(created.Subject)
+	}
+	return "ip=" + created.ClientIP
+}
+
+/* [Internal function] Record limited request, then outputs 429 error with Retry-After */
+func tooManyRequests(ctx echo.Context, requestID string, retryAfter int, reason string) error {
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	printLog("warning", "Export limited (" + requestID + ", " + consumerID(ctx) + "): " + reason)
+	recordEvent(ctx, audit.Record{Event: "Limited", RequestID: requestID, Detail: reason})
+	ctx.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return catchError(ctx, &echo.HTTPError{Code: http.StatusTooManyRequests, Message: reason})
+}
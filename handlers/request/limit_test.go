@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/controllers/job"
+	"dems-api-server/controllers/storage"
+)
+
+func TestRateLimitJobs(t *testing.T) {
+	requestID := "limit-jobs"
+	if err := storage.SaveDefinition(requestID, json.RawMessage(`{}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	// 작업은 종료할 때까지 실행 중으로 유지
+	block := make(chan struct{})
+	if err := job.Start(echo.New(), func(ctx echo.Context) error {
+		<-block
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	call := func(handler echo.HandlerFunc, method string, remoteAddr string) int {
+		req := httptest.NewRequest(method, "/request/"+requestID+"/jobs", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		ctx := echo.New().NewContext(req, rec)
+		ctx.SetParamNames("requestID")
+		ctx.SetParamValues(requestID)
+		handler(ctx)
+		return rec.Code
+	}
+	createJob := RateLimit(CreateJob)
+	export := RateLimit(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	// 기본값 동시 반출 2건 (대기 및 실행 중인 작업 포함)
+	for i := 0; i < 2; i++ {
+		if code := call(createJob, http.MethodPost, "192.0.2.10:1000"); code != http.StatusAccepted {
+			t.Fatalf("job %d: status = %d", i, code)
+		}
+	}
+	if code := call(createJob, http.MethodPost, "192.0.2.10:1000"); code != http.StatusTooManyRequests {
+		t.Fatalf("third job: status = %d", code)
+	}
+	if code := call(export, http.MethodGet, "192.0.2.10:1000"); code != http.StatusTooManyRequests {
+		t.Fatalf("export with two jobs: status = %d", code)
+	}
+	// 다른 클라이언트는 별도로 제한
+	if code := call(createJob, http.MethodPost, "192.0.2.11:1000"); code != http.StatusAccepted {
+		t.Fatalf("job of other client: status = %d", code)
+	}
+	if count, err := storage.CountEvent(requestID, "Limited"); err != nil || count != 2 {
+		t.Fatalf("limited events = %d (%v)", count, err)
+	}
+
+	// 작업이 끝나면 다시 허용
+	close(block)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(job.Active(requestID)) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("active jobs = %d", len(job.Active(requestID)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := call(createJob, http.MethodPost, "192.0.2.10:1000"); code != http.StatusAccepted {
+		t.Fatalf("job after finished jobs: status = %d", code)
+	}
+}
@@ -1,20 +1,31 @@
 package handlers
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
-	"path"
+	"strconv"
 	"strings"
 	"time"
 	// Echo
 	echo "github.com/labstack/echo"
 	// Custom package
+	"dems-api-server/configs"
 	hdb "dems-api-server/controllers/query"
 	anony "dems-api-server/controllers/anonymous"
+	"dems-api-server/controllers/artifact"
+	"dems-api-server/controllers/audit"
+	"dems-api-server/controllers/export"
+	"dems-api-server/controllers/manifest"
+	"dems-api-server/controllers/storage"
+	"dems-api-server/controllers/usage"
+	"dems-api-server/controllers/worker"
 )
 
 const (
@@ -22,6 +33,12 @@ const (
 	DU_KB = 1024
 	DU_MB = DU_KB * 1024
 	DU_GM = DU_MB * 1024
+	// Number of rows buffered between stages (query, anonymization, save)
+	QUEUE_SIZE = DU_KB * 4
+	// Export status (X-Export-Status trailer)
+	ES_SUCCESS = "success"
+	ES_FAILED = "failed"
+	ES_ABORTED = "aborted"
 )
 
 // Response structrue
@@ -36,75 +53,40 @@ type ResponseList struct {
 // Database interface
 type ConnectionDB struct {
 	db *sql.DB
-	syntax string
+	dialect hdb.Dialect
+	query *hdb.SelectQuery
 	// Data size
 	totalSize uint64
 	blockSize uint64
 }
 
 func RequestList(ctx echo.Context) error {
-	// Get workspace
-	workspace, err := os.Getwd()
-	if e := catchError(ctx, err); e != nil {
-		return e
-	}
-	// Set directory path
-	dirPath := path.Join(workspace, "./resources/processed")
-	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		printLog("error", err.Error())
-		// Create directory with optional files
-		err := os.MkdirAll(dirPath, 0644)
-		if e:= catchError(ctx, err); e != nil {
-			return e
-		}
-	}
-	// Open directory with optional files
-	dir, err := os.Open(dirPath)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	// Get defined requests
+	requestIDs, err := storage.RequestIDs()
+	if err != nil {
+		return catchError(ctx, err)
 	}
-	defer dir.Close()
-	// Get directory info
-	info, err := dir.Readdir(-1)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	// Get export history data for each request
+	counts, err := storage.CountEvents()
+	if err != nil {
+		return catchError(ctx, err)
 	}
 
 	// Create obj to output
-	accessObj := make(map[string](map[string]int), len(info))
-	for _, file := range info {
-		accessObj[file.Name()] = map[string]int {
-			"attempt": 0,
-			"success": 0,
-			"failed": 0,
-		}
-	}
-	// Lookup log file to know export history
-	logFilePath := path.Join(workspace, "./resources/logs/access.log")
-	if e := catchError(ctx, err); e != nil {
-		return e
-	}
-	logFile, err := os.Open(logFilePath)
-	if e := catchError(ctx, err); e != nil {
-		return e
-	}
-	defer logFile.Close()
-	// Get export history data for each request
-	reader := bufio.NewReader(logFile)
-	for {
-		line, isPrefix, err := reader.ReadLine()
-		if isPrefix || err != nil {
-			break
+	accessObj := make(map[string](map[string]int), len(requestIDs))
+	// API key shows only its own request
+	key := requestKey(ctx)
+	for _, requestID := range requestIDs {
+		if key != nil && requestID != key.RequestID {
+			continue
 		}
-
-		split := strings.Split(string(line), " ")
-		switch split[1] {
-			case "[Attempt]":
-				accessObj[split[2]]["attempt"] += 1
-			case "[Success]":
-				accessObj[split[2]]["success"] += 1
-			case "[Failed]":
-				accessObj[split[2]]["failed"] += 1
+		accessObj[requestID] = map[string]int {
+			"attempt": counts[requestID]["Attempt"],
+			"success": counts[requestID]["Success"],
+			"failed": counts[requestID]["Failed"],
+			"aborted": counts[requestID]["Aborted"],
+			"denied": counts[requestID]["Denied"],
+			"limited": counts[requestID]["Limited"],
 		}
 	}
 
@@ -120,87 +102,392 @@ func RequestList(ctx echo.Context) error {
 func ExportRequest(ctx echo.Context) error {
 	var err error
 	requestID := ctx.Param("requestID")
+	// 반출 이력 및 감사 로그에 기록할 수 없으면 반출하지 않음
+	if err := recordEvent(ctx, audit.Record{Event: "Attempt", RequestID: requestID}); err != nil {
+		return catchError(ctx, errors.New("Failed to record export"))
+	}
+	// Check usage period and export quota of request (the export is counted until it finishes)
+	policy, err := usage.LoadPolicy(requestID)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	release, err := policy.Acquire(requestID, time.Now())
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	defer release()
+	// Get export options of request (overridden by query parameters)
+	exportOptions, err := export.LoadOptions(requestID)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	if err := exportOptions.CSV.Merge(ctx.QueryParams()); err != nil {
+		return failExport(ctx, requestID, &echo.HTTPError{Code: http.StatusBadRequest, Message: err.Error()})
+	}
+	format := export.NegotiateFormat(ctx.QueryParam("format"), ctx.Request().Header.Get(echo.HeaderAccept), exportOptions)
+	// Export ID (the signed manifest of the export is retrieved by this ID)
+	exportedAt := time.Now()
+	exportID, err := manifest.NewExportID(exportedAt)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	ctx.Response().Header().Set("X-Export-ID", exportID)
+	// Keep the response body in the artifact store (resumable download after the export)
+	store := artifact.Create(exportID)
+	defer store.Discard()
+	// Digest of the response body (recorded in the manifest)
+	digest := manifest.NewDigest(io.MultiWriter(ctx.Response(), store))
+	// Encrypt to recipients declared by the request (compressed data is encrypted)
+	encryptor, err := export.NewEncryptor(digest, exportOptions.Encryption)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	// Compress by compress parameter (compressed file) or Accept-Encoding header
+	acceptEncoding := ctx.Request().Header.Get(echo.HeaderAcceptEncoding)
+	if encryptor.Enabled() {
+		// 암호화된 응답은 Content-Encoding으로 압축하지 않음 (압축 파일은 암호화 전에 압축)
+		acceptEncoding = ""
+	}
+	compression, err := export.NegotiateCompression(ctx.QueryParam("compress"), acceptEncoding, format)
+	if err != nil {
+		return failExport(ctx, requestID, &echo.HTTPError{Code: http.StatusBadRequest, Message: err.Error()})
+	}
+	stream, err := export.NewCompressor(encryptor, compression, configs.Get().Export.Compression)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	// Release compression stream if the export does not finish (after the save routine exits)
+	defer stream.Abort()
+	writer, err := export.NewWriter(format, stream, exportOptions)
+	if err != nil {
+		return failExport(ctx, requestID, &echo.HTTPError{Code: http.StatusBadRequest, Message: err.Error()})
+	}
+	// Export context (canceled when the client disconnects or the export times out)
+	var exportCtx context.Context
+	var cancel context.CancelFunc
+	if timeout := configs.Get().Export.Timeout; timeout > 0 {
+		exportCtx, cancel = context.WithTimeout(ctx.Request().Context(), time.Duration(timeout) * time.Second)
+	} else {
+		exportCtx, cancel = context.WithCancel(ctx.Request().Context())
+	}
+	defer cancel()
 	// Create database interface
 	conn := new(ConnectionDB)
-	conn.db, err = hdb.CreateConnection(requestID)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	conn.db, err = hdb.CreateConnection(exportCtx, requestID)
+	if err != nil {
+		return failExport(ctx, requestID, err)
 	}
 	defer conn.db.Close()
 	// Set block size
 	conn.blockSize = 100000
 
-	// Create query syntax
-	conn.syntax, err = hdb.CreateQuerySyntax(requestID)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	// Get SQL dialect of data source
+	conn.dialect, err = hdb.LoadDialect(requestID)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	// Create query model
+	conn.query, err = hdb.CreateQuery(requestID)
+	if err != nil {
+		return failExport(ctx, requestID, err)
 	}
 	// Outputs the total number of query result
-	conn.totalSize, err = hdb.GetDataSize(conn.db, conn.syntax)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	conn.totalSize, err = hdb.GetDataSize(exportCtx, conn.db, conn.dialect, conn.query)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	if err := policy.CheckRows(conn.totalSize); err != nil {
+		return failExport(ctx, requestID, err)
 	}
-	writeLog("access", "[Attempt] " + requestID)
 
-	// Calculate the number of split queries basesd on the specified blocksize
-	nProc := conn.totalSize / conn.blockSize
-	if conn.totalSize % conn.blockSize > 0 {
-		nProc += 1
+	// Split queries into key ranges basesd on the specified blocksize
+	partitions, err := hdb.CreatePartitions(exportCtx, conn.db, conn.dialect, conn.query, conn.blockSize, conn.totalSize)
+	if err != nil {
+		return failExport(ctx, requestID, err)
 	}
+	nProc := uint64(len(partitions))
 	
 	// Create header to used in csv file
-	header, err := hdb.GetDataColumns(conn.db, conn.syntax)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	header, err := hdb.GetDataColumns(exportCtx, conn.db, conn.dialect, conn.query)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	// Set column types for typed format (anonymized columns are exported as string)
+	if typedWriter, ok := writer.(export.TypedWriter); ok {
+		columns, err := hdb.GetColumnTypes(exportCtx, conn.db, conn.dialect, conn.query)
+		if err != nil {
+			return failExport(ctx, requestID, err)
+		}
+		transformed, err := anony.TransformedColumns(requestID, header)
+		if err != nil {
+			return failExport(ctx, requestID, err)
+		}
+		for i := range columns {
+			if transformed[i] {
+				columns[i].Type = hdb.VT_STRING
+			}
+		}
+		typedWriter.SetColumnTypes(columns)
+	}
+	// Set export metadata (request, anonymization method of each column)
+	if metadataWriter, ok := writer.(export.MetadataWriter); ok {
+		options, err := anony.ColumnOptions(requestID, header)
+		if err != nil {
+			return failExport(ctx, requestID, err)
+		}
+		metadata := export.Metadata{RequestID: requestID, ExportedAt: exportedAt}
+		for i, option := range options {
+			metadata.Columns = append(metadata.Columns, export.ColumnMetadata{Name: header[i], Method: option.Method, Description: option.Description})
+		}
+		metadataWriter.SetMetadata(metadata)
 	}
 
-	// Create channel(queue)
-	rawDataQueue := make(chan []string, DU_MB * 256)
-	pcdDataQueue := make(chan []string, DU_MB * 256)
-	nProcQuery := make(chan bool, int(nProc))
-	nProcAnony := make(chan bool, int(nProc))
-	// stateQuery := make(chan bool)
-	quitProc := make(chan bool)
+	// Reserve anonymization workers (one worker waits for a server-wide slot, the rest only if the server has free slots)
+	if err := worker.AnonyPool().AcquireContext(exportCtx); err != nil {
+		return failExport(ctx, requestID, err)
+	}
+	defer worker.AnonyPool().Release()
+	anonyLimit := uint64(configs.Get().Worker.AnonyPerExport)
+	if anonyLimit > nProc {
+		anonyLimit = nProc
+	}
+	extraAnony := 0
+	if anonyLimit > 1 {
+		extraAnony = worker.AnonyPool().TryAcquire(int(anonyLimit) - 1)
+	}
+	defer worker.AnonyPool().ReleaseN(extraAnony)
+	nAnony := uint64(1 + extraAnony)
+
+	// Create channel(queue), each stage reports its result (nil or error) when it exits
+	rawDataQueue := make(chan hdb.Row, QUEUE_SIZE)
+	pcdDataQueue := make(chan hdb.Row, QUEUE_SIZE)
+	nProcQuery := make(chan error, int(nProc))
+	nProcAnony := make(chan error, int(nAnony))
+	quitProc := make(chan anony.SaveResult, 1)
 
 	// Excute query
-	_, err = hdb.ExecuteQuery(conn.db, conn.syntax, conn.blockSize, nProc, rawDataQueue, nProcQuery)
-	if e := catchError(ctx, err); e != nil {
-		return e
+	_, err = hdb.ExecuteQuery(exportCtx, conn.db, conn.dialect, conn.query, partitions, rawDataQueue, nProcQuery)
+	if err != nil {
+		return failExport(ctx, requestID, err)
 	}
 	// Process anonymization
-	anony.Anonymization(requestID, nProc, header, rawDataQueue, pcdDataQueue, nProcAnony)
+	err = anony.Anonymization(exportCtx, requestID, nAnony, header, rawDataQueue, pcdDataQueue, nProcAnony)
+	if err != nil {
+		return failExport(ctx, requestID, err)
+	}
 	// Save data
-	go anony.SaveData(ctx.Response(), header, pcdDataQueue, quitProc)
+	go anony.SaveData(exportCtx, ctx.Response(), writer, stream, encryptor, header, pcdDataQueue, quitProc)
 
-	// 채널에 데이터 유무 확인 후, 채널 종료 처리 및 루프 종료 처리
+	// 채널에 데이터 유무 확인 후, 채널 종료 처리 및 루프 종료 처리 (처음 발생한 오류에서 반출 중단)
 	completedQuery := 0
 	completedAnony := 0
+	saved := false
+	var result anony.SaveResult
+	var exportErr error
 	ProcLoop:
 	for {
 		select {
-			case <-nProcQuery:
+			case err := <-nProcQuery:
+				if err != nil {
+					exportErr = err
+					break ProcLoop
+				}
 				completedQuery++
 				if uint64(completedQuery) >= nProc {
 					close(rawDataQueue)
 				}
-			case <-nProcAnony:
+			case err := <-nProcAnony:
+				if err != nil {
+					exportErr = err
+					break ProcLoop
+				}
 				completedAnony++
-				if uint64(completedAnony) >= nProc {
+				if uint64(completedAnony) >= nAnony {
 					close(pcdDataQueue)
 				}
-			case <-quitProc:
+			case result = <-quitProc:
+				saved = true
+				exportErr = result.Err
+				break ProcLoop
+			case <-exportCtx.Done():
+				exportErr = exportCtx.Err()
 				break ProcLoop
 		}
 	}
+
+	if exportErr != nil {
+		// Stop the other stages and wait until the response is no longer written
+		cancel()
+		if !saved {
+			result = <-quitProc
+		}
+		// Client disconnected or timed out (write error means the client is gone)
+		if saved || errors.Is(exportErr, context.Canceled) || errors.Is(exportErr, context.DeadlineExceeded) {
+			printLog("warning", "Export aborted (" + requestID + "): " + exportErr.Error())
+			recordEvent(ctx, audit.Record{Event: "Aborted", RequestID: requestID, Rows: result.Rows, Detail: strings.Join(strings.Fields(exportErr.Error()), " ")})
+			setExportTrailer(ctx, result.Rows, ES_ABORTED)
+			return nil
+		}
+		setExportTrailer(ctx, result.Rows, ES_FAILED)
+		return failExport(ctx, requestID, exportErr)
+	}
 	
 	printLog("debug", "Exported data")
-	writeLog("access", "[Success] " + requestID)
+	// Hash of anonymization options (recorded in the audit log and the manifest)
+	optionsDigest, optionsErr := anony.OptionsDigest(requestID)
+	recordEvent(ctx, audit.Record{Event: "Success", RequestID: requestID, Rows: result.Rows, OptionsSHA256: optionsDigest, SHA256: digest.Sum()})
+	// The response body is the exported file only, so the result is sent as trailer
+	setExportTrailer(ctx, result.Rows, ES_SUCCESS)
+	ctx.Response().Header().Set("X-Export-SHA256", digest.Sum())
+	// Sign and save manifest of the export (the response is already sent, so failure is only logged)
+	if optionsErr != nil {
+		printLog("error", "Failed to create manifest (" + requestID + "): " + optionsErr.Error())
+		return nil
+	}
+	exportManifest := &manifest.Manifest{
+		ExportID: exportID,
+		RequestID: requestID,
+		ExportedAt: exportedAt.UTC().Format(time.RFC3339),
+		Rows: result.Rows,
+		Columns: header,
+		OptionsSHA256: optionsDigest,
+		Format: format,
+		Compression: stream.Method,
+		ContentEncoding: stream.ContentEncodingHeader(),
+		Encryption: encryptor.Type(),
+		FileName: export.FileName(writer, stream, encryptor),
+		Size: digest.Size(),
+		SHA256: digest.Sum(),
+	}
+	if err := manifest.Save(exportManifest); err != nil {
+		printLog("error", "Failed to create manifest (" + requestID + "): " + err.Error())
+	}
+	exportArtifact := &artifact.Artifact{
+		RequestID: requestID,
+		FileName: exportManifest.FileName,
+		ContentType: ctx.Response().Header().Get(echo.HeaderContentType),
+		ContentEncoding: exportManifest.ContentEncoding,
+		Size: exportManifest.Size,
+		SHA256: exportManifest.SHA256,
+	}
+	if err := store.Commit(exportArtifact); err != nil {
+		printLog("error", "Failed to save export (" + requestID + "): " + err.Error())
+	}
+	return nil
+}
 
-	message := &ResponseMessage{
-		Result: true,
-		Message: []string{""},
+/* [Function] Signed manifest of export */
+func ExportManifest(ctx echo.Context) error {
+	// 서명된 문서를 그대로 전송 (?pretty 등으로 다시 작성하지 않음)
+	signed, err := manifest.Read(ctx.Param("requestID"), ctx.Param("exportID"))
+	if os.IsNotExist(err) {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Manifest not found"})
+	} else if err != nil {
+		return catchError(ctx, err)
 	}
-	return ctx.JSON(http.StatusOK, message)
+	return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, signed)
+}
+
+/* [Function] Download finished export (supports Range, If-Range and ETag for resuming) */
+func DownloadExport(ctx echo.Context) error {
+	exportArtifact, filePath, err := artifact.Get(ctx.Param("requestID"), ctx.Param("exportID"))
+	if os.IsNotExist(err) {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotFound, Message: "Export not found"})
+	} else if err == artifact.ErrExpired {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusGone, Message: err.Error()})
+	} else if err != nil {
+		return catchError(ctx, err)
+	}
+	if err := checkPeriod(exportArtifact.RequestID); err != nil {
+		return failUsage(ctx, exportArtifact.RequestID, err)
+	}
+	return serveArtifact(ctx, exportArtifact, filePath)
+}
+
+/* [Function] Public key to verify manifest signatures */
+func ManifestPublicKey(ctx echo.Context) error {
+	publicKey, err := manifest.GetPublicKey()
+	if err != nil {
+		return catchError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, publicKey)
+}
+
+/* [Internal function] Check usage period before serving stored export (denied after validUntil) */
+func checkPeriod(requestID string) error {
+	policy, err := usage.LoadPolicy(requestID)
+	if err != nil {
+		return err
+	}
+	return policy.CheckPeriod(time.Now())
+}
+
+/* [Internal function] Outputs 403 error if export is denied by the usage policy */
+func failUsage(ctx echo.Context, requestID string, err error) error {
+	if denied, ok := err.(*usage.DeniedError); ok {
+		return denyExport(ctx, requestID, denied.Reason)
+	}
+	return catchError(ctx, err)
+}
+
+/* [Internal function] Serve artifact file (range and conditional requests are handled by http.ServeContent) */
+func serveArtifact(ctx echo.Context, exportArtifact *artifact.Artifact, filePath string) error {
+	// 압축된 응답을 그대로 보관하므로 같은 Content-Encoding을 받을 수 있는 경우만 전송
+	ctx.Response().Header().Set("Vary", "Accept-Encoding")
+	if encoding := exportArtifact.ContentEncoding; encoding != "" && !export.AcceptsEncoding(ctx.Request().Header.Get(echo.HeaderAcceptEncoding), encoding) {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusNotAcceptable, Message: "Export is stored with Content-Encoding " + encoding + " (Accept-Encoding: " + encoding + " is required)"})
+	}
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return catchError(ctx, &echo.HTTPError{Code: http.StatusGone, Message: artifact.ErrExpired.Error()})
+	} else if err != nil {
+		return catchError(ctx, err)
+	}
+	defer file.Close()
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentDisposition, "attachment;filename=" + exportArtifact.FileName)
+	header.Set(echo.HeaderContentType, exportArtifact.ContentType)
+	if exportArtifact.ContentEncoding != "" {
+		header.Set(echo.HeaderContentEncoding, exportArtifact.ContentEncoding)
+	}
+	// 내용이 바뀌지 않으므로 SHA-256을 strong ETag로 사용
+	header.Set("ETag", "\"" + exportArtifact.SHA256 + "\"")
+	header.Set("X-Export-ID", exportArtifact.ExportID)
+	header.Set("X-Export-SHA256", exportArtifact.SHA256)
+	http.ServeContent(ctx.Response(), ctx.Request(), exportArtifact.FileName, exportArtifact.CreatedAt, file)
+	return nil
+}
+
+/* [Internal function] Record failed export, then outputs error if the stream has not started yet */
+func failExport(ctx echo.Context, requestID string, err error) error {
+	if denied, ok := err.(*usage.DeniedError); ok {
+		return denyExport(ctx, requestID, denied.Reason)
+	}
+	printLog("error", "Export failed (" + requestID + "): " + err.Error())
+	// Rows already sent if the stream has started
+	rows, _ := strconv.ParseUint(ctx.Response().Header().Get("X-Export-Rows"), 10, 64)
+	recordEvent(ctx, audit.Record{Event: "Failed", RequestID: requestID, Rows: rows, Detail: strings.Join(strings.Fields(err.Error()), " ")})
+	// Status and header are already sent (result is in trailer)
+	if ctx.Response().Committed {
+		return nil
+	}
+	return catchError(ctx, err)
+}
+
+/* [Internal function] Record export denied by usage or access policy, then outputs 403 error */
+func denyExport(ctx echo.Context, requestID string, reason string) error {
+	printLog("warning", "Export denied (" + requestID + "): " + reason)
+	recordEvent(ctx, audit.Record{Event: "Denied", RequestID: requestID, Detail: reason})
+	return catchError(ctx, &echo.HTTPError{Code: http.StatusForbidden, Message: reason})
+}
+
+/* [Internal function] Set end-of-stream trailer (declared by the Trailer header before streaming) */
+func setExportTrailer(ctx echo.Context, rows uint64, status string) {
+	if !ctx.Response().Committed {
+		return
+	}
+	ctx.Response().Header().Set("X-Export-Rows", strconv.FormatUint(rows, 10))
+	ctx.Response().Header().Set("X-Export-Status", status)
 }
 
 /* [Internal function] Outputs errors that occur during processing and terminates the process */
@@ -211,6 +498,11 @@ func catchError(ctx echo.Context, err error) error {
 			Result: false,
 			Message: []string{err.Error()},
 		}
+		// Status code of HTTP error (e.g. invalid parameter)
+		if he, ok := err.(*echo.HTTPError); ok {
+			message.Message = []string{fmt.Sprint(he.Message)}
+			return ctx.JSON(he.Code, message)
+		}
 		// Return
 		return ctx.JSON(http.StatusInternalServerError, message)
 	} else {
@@ -236,37 +528,4 @@ func printLog(pType string, message string) {
 	buffer.WriteString("\u001B[0m")
 	// Print message
 	log.Print(buffer.String())
-}
-
-/* [Internal function] Write log for statistics processing */
-func writeLog(logType string, message string) {
-	// Get workspace
-	workspace, err := os.Getwd()
-	if err != nil {
-		printLog("error", err.Error())
-		return
-	}
-	
-	dirPath := path.Join(workspace, "./resources/logs")
-	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		err := os.MkdirAll(dirPath, 0644)
-		if err != nil {
-			printLog("error", err.Error())
-			return
-		}
-	}
-
-	logFileName := logType + ".log"
-	logFilePath := path.Join(dirPath, logFileName)
-	file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		printLog("error", err.Error())
-		return
-	}
-	defer file.Close()
-
-	today := time.Now()
-	file.WriteString(today.Format("2006-01-02T15:04:05 "))
-	file.WriteString(message)
-	file.WriteString("\n")
 }
\ No newline at end of file
@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	// Echo
+	echo "github.com/labstack/echo"
+	// Custom package
+	"dems-api-server/configs"
+	"dems-api-server/controllers/artifact"
+	"dems-api-server/controllers/manifest"
+	"dems-api-server/controllers/storage"
+)
+
+// Rows of the source table (more than one block, so the export is split into partitions)
+const sourceRows = 250000
+
+func TestMain(m *testing.M) {
+	// 설정, 데이터베이스, 로그, 반출 파일은 모두 작업 경로 기준이므로 임시 디렉토리에서 실행
+	workspace, err := ioutil.TempDir("", "dems-handlers")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(workspace); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	os.RemoveAll(workspace)
+	os.Exit(code)
+}
+
+func TestExportRequestSQLite(t *testing.T) {
+	source := createSource(t)
+	requestID := "sqlite-export"
+	query := `{
+		"conn": { "type": "sqlite", "path": "` + source + `", "database": "main", "table": "PROFILES" },
+		"attributes": {
+			"AGE": { "isExport": true },
+			"NAME": { "isExport": true, "isPii": true, "consentDatabase": "main", "consentTable": "CONSENTS", "legalDuration": 12 },
+			"PROFILES_ID": { "isExport": true },
+			"MEMO": { "isExport": false }
+		}
+	}`
+	options := `{ "NAME": { "method": "encryption", "options": { "algorithm": "hash(sha256)" } } }`
+	if err := storage.SaveDefinition(requestID, json.RawMessage(query), json.RawMessage(options)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/request/"+requestID, nil)
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(req, rec)
+	ctx.SetParamNames("requestID")
+	ctx.SetParamValues(requestID)
+	if err := ExportRequest(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	// 동의하지 않은 데이터(10건 중 1건)는 반출되지 않음
+	expected := sourceRows - sourceRows/10
+	if status := rec.Header().Get("X-Export-Status"); status != ES_SUCCESS {
+		t.Fatalf("X-Export-Status = %q", status)
+	}
+	if rows := rec.Header().Get("X-Export-Rows"); rows != strconv.Itoa(expected) {
+		t.Fatalf("X-Export-Rows = %s, want %d", rows, expected)
+	}
+
+	// 헤더는 속성 이름 순서, 각 키는 한 번씩만 반출 (분할 쿼리 사이에 누락 및 중복 없음)
+	reader := csv.NewReader(strings.NewReader(rec.Body.String()))
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(records[0], ","); got != "AGE,NAME,PROFILES_ID" {
+		t.Fatalf("header = %s", got)
+	}
+	if len(records)-1 != expected {
+		t.Fatalf("rows = %d, want %d", len(records)-1, expected)
+	}
+	seen := make(map[int]bool, expected)
+	for _, record := range records[1:] {
+		id, err := strconv.Atoi(record[2])
+		if err != nil {
+			t.Fatalf("invalid key: %v", record)
+		}
+		if seen[id] {
+			t.Fatalf("key %d exported twice", id)
+		}
+		seen[id] = true
+		if id%10 == 0 {
+			t.Fatalf("key %d exported without consent", id)
+		}
+		// 비식별화 (NAME은 SHA-256), NULL은 빈 값으로 유지
+		digest := sha256.Sum256([]byte("name-" + strconv.Itoa(id)))
+		if record[1] != hex.EncodeToString(digest[:]) {
+			t.Fatalf("NAME of %d is not anonymized: %s", id, record[1])
+		}
+		if id%7 == 0 {
+			if record[0] != "" {
+				t.Fatalf("AGE of %d = %q, want NULL", id, record[0])
+			}
+		} else if record[0] != strconv.Itoa(id%90) {
+			t.Fatalf("AGE of %d = %q", id, record[0])
+		}
+	}
+
+	// 반출 이력 기록
+	if count, err := storage.CountEvent(requestID, "Success"); err != nil || count != 1 {
+		t.Fatalf("success events = %d (%v)", count, err)
+	}
+}
+
+func TestDownloadExportUsagePeriod(t *testing.T) {
+	requestID := "download-period"
+	exportID, err := manifest.NewExportID(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := artifact.Create(exportID)
+	store.Write([]byte("A\r\n1\r\n"))
+	if err := store.Commit(&artifact.Artifact{RequestID: requestID, FileName: "exportData.csv", ContentType: "text/csv"}); err != nil {
+		t.Fatal(err)
+	}
+	download := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/request/"+requestID+"/exports/"+exportID, nil)
+		rec := httptest.NewRecorder()
+		ctx := echo.New().NewContext(req, rec)
+		ctx.SetParamNames("requestID", "exportID")
+		ctx.SetParamValues(requestID, exportID)
+		if err := DownloadExport(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	// 활용 기간 내에는 보관된 반출 파일 다운로드 가능
+	validUntil := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	if err := storage.SaveDefinition(requestID, json.RawMessage(`{ "usage": { "validUntil": "`+validUntil+`", "maxExports": 1 } }`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rec := download(); rec.Code != http.StatusOK || rec.Body.String() != "A\r\n1\r\n" {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	// 활용 기간이 지나면 보관 기간 내라도 거부
+	validUntil = time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if err := storage.SaveDefinition(requestID, json.RawMessage(`{ "usage": { "validUntil": "`+validUntil+`" } }`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if rec := download(); rec.Code != http.StatusForbidden {
+		t.Fatalf("status after validUntil = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if count, err := storage.CountEvent(requestID, "Denied"); err != nil || count != 1 {
+		t.Fatalf("denied events = %d (%v)", count, err)
+	}
+}
+
+func TestDownloadExportRange(t *testing.T) {
+	requestID := "download-range"
+	if err := storage.SaveDefinition(requestID, json.RawMessage(`{}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	content := "ID,NAME\r\n1,a\r\n2,b\r\n"
+	digest := sha256.Sum256([]byte(content))
+	etag := `"` + hex.EncodeToString(digest[:]) + `"`
+	commit := func(contentEncoding string) string {
+		exportID, err := manifest.NewExportID(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		store := artifact.Create(exportID)
+		store.Write([]byte(content))
+		exportArtifact := &artifact.Artifact{RequestID: requestID, FileName: "exportData.csv", ContentType: "text/csv", ContentEncoding: contentEncoding, SHA256: hex.EncodeToString(digest[:])}
+		if err := store.Commit(exportArtifact); err != nil {
+			t.Fatal(err)
+		}
+		return exportID
+	}
+	download := func(exportID string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/request/"+requestID+"/exports/"+exportID, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		ctx := echo.New().NewContext(req, rec)
+		ctx.SetParamNames("requestID", "exportID")
+		ctx.SetParamValues(requestID, exportID)
+		if err := DownloadExport(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	exportID := commit("")
+	rec := download(exportID, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != content || rec.Header().Get("ETag") != etag {
+		t.Fatalf("status = %d, ETag = %s, body = %q", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	// 이어받기
+	rec = download(exportID, http.Header{"Range": {"bytes=9-"}})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != content[9:] ||
+		rec.Header().Get("Content-Range") != "bytes 9-"+strconv.Itoa(len(content)-1)+"/"+strconv.Itoa(len(content)) {
+		t.Fatalf("status = %d, Content-Range = %s, body = %q", rec.Code, rec.Header().Get("Content-Range"), rec.Body.String())
+	}
+	rec = download(exportID, http.Header{"Range": {"bytes=0-1"}, "If-Range": {etag}})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != content[:2] {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	// 다른 반출 파일의 ETag이면 전체 전송
+	rec = download(exportID, http.Header{"Range": {"bytes=0-1"}, "If-Range": {`"stale"`}})
+	if rec.Code != http.StatusOK || rec.Body.String() != content {
+		t.Fatalf("stale If-Range: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if rec = download(exportID, http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match: status = %d", rec.Code)
+	}
+
+	// 압축된 반출은 같은 Content-Encoding을 받을 수 있는 경우만 전송
+	exportID = commit("gzip")
+	for _, acceptEncoding := range []string{"", "identity", "zstd", "gzip;q=0", "*;q=0", "*, gzip;q=0"} {
+		if rec = download(exportID, http.Header{"Accept-Encoding": {acceptEncoding}}); rec.Code != http.StatusNotAcceptable {
+			t.Fatalf("Accept-Encoding %q: status = %d", acceptEncoding, rec.Code)
+		}
+	}
+	for _, acceptEncoding := range []string{"gzip", "zstd, gzip;q=0.5", "x-gzip", "*", "zstd;q=0, *"} {
+		rec = download(exportID, http.Header{"Accept-Encoding": {acceptEncoding}})
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("Accept-Encoding %q: status = %d, Content-Encoding = %q", acceptEncoding, rec.Code, rec.Header().Get("Content-Encoding"))
+		}
+	}
+
+	// 보관 기간이 지난 반출
+	config := &configs.Get().Artifact
+	retention := config.Retention
+	config.Retention = 0
+	defer func() { config.Retention = retention }()
+	if rec = download(commit(""), nil); rec.Code != http.StatusGone {
+		t.Fatalf("expired: status = %d", rec.Code)
+	}
+}
+
+func TestManifestSignature(t *testing.T) {
+	requestID := "manifest-signature"
+	exportID, err := manifest.NewExportID(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportManifest := &manifest.Manifest{ExportID: exportID, RequestID: requestID, Rows: 1, Columns: []string{"NAME"}, Format: "csv", FileName: "exportData.csv"}
+	if err := manifest.Save(exportManifest); err != nil {
+		t.Fatal(err)
+	}
+	call := func(handler echo.HandlerFunc, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, target, nil), rec)
+		ctx.SetParamNames("requestID", "exportID")
+		ctx.SetParamValues(requestID, exportID)
+		if err := handler(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", target, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	// ?pretty를 지정해도 저장된 문서 그대로 전송
+	rec := call(ExportManifest, "/request/"+requestID+"/exports/"+exportID+"/manifest?pretty")
+	stored, err := manifest.Read(requestID, exportID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), stored) {
+		t.Fatalf("manifest = %s, stored = %s", rec.Body.String(), stored)
+	}
+	signed := new(manifest.SignedManifest)
+	if err := json.Unmarshal(rec.Body.Bytes(), signed); err != nil {
+		t.Fatal(err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 공개키 (PEM과 raw 키가 같은 키)
+	publicKey := new(manifest.PublicKey)
+	if err := json.Unmarshal(call(ManifestPublicKey, "/manifest/key?pretty").Body.Bytes(), publicKey); err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(publicKey.PEM))
+	if block == nil {
+		t.Fatalf("pem = %q", publicKey.PEM)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, ok := parsed.(ed25519.PublicKey)
+	if !ok || base64.StdEncoding.EncodeToString(public) != publicKey.PublicKey {
+		t.Fatalf("public key = %T %s", parsed, publicKey.PublicKey)
+	}
+	if signed.Algorithm != manifest.ALGORITHM || signed.KeyID != publicKey.KeyID {
+		t.Fatalf("algorithm = %s, key ID = %s, want %s", signed.Algorithm, signed.KeyID, publicKey.KeyID)
+	}
+	if !ed25519.Verify(public, signed.Manifest, signature) {
+		t.Fatal("signature is not verified")
+	}
+	// 변경된 매니페스트는 검증 실패
+	tampered := bytes.Replace(signed.Manifest, []byte(`"rows":1`), []byte(`"rows":2`), 1)
+	if bytes.Equal(tampered, signed.Manifest) || ed25519.Verify(public, tampered, signature) {
+		t.Fatal("tampered manifest is verified")
+	}
+	content := new(manifest.Manifest)
+	if err := json.Unmarshal(signed.Manifest, content); err != nil || content.ExportID != exportID || content.RequestID != requestID {
+		t.Fatalf("manifest = %s (%v)", signed.Manifest, err)
+	}
+}
+
+// Response writer of the export tests (hook is called before each write)
+type hookedWriter struct {
+	*httptest.ResponseRecorder
+	hook func() error
+}
+
+func (w *hookedWriter) Write(p []byte) (int, error) {
+	if err := w.hook(); err != nil {
+		return 0, err
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+/* [Internal function] Save request definition that exports key and age of the source */
+func saveExportDefinition(t *testing.T, requestID string, source string, table string) {
+	query := `{
+		"conn": { "type": "sqlite", "path": "` + source + `", "database": "main", "table": "` + table + `" },
+		"attributes": { "PROFILES_ID": { "isExport": true }, "AGE": { "isExport": true } }
+	}`
+	if err := storage.SaveDefinition(requestID, json.RawMessage(query), json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+/* [Internal function] Call export handler with request context and response writer */
+func exportWith(t *testing.T, reqCtx context.Context, requestID string, w http.ResponseWriter) {
+	req := httptest.NewRequest(http.MethodGet, "/request/"+requestID, nil).WithContext(reqCtx)
+	ctx := echo.New().NewContext(req, w)
+	ctx.SetParamNames("requestID")
+	ctx.SetParamValues(requestID)
+	if err := ExportRequest(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+/* [Internal function] Check that no stage of the export is left (query, anonymization and save routines) */
+func checkStages(t *testing.T) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		buf := make([]byte, 1<<20)
+		stacks := string(buf[:runtime.Stack(buf, true)])
+		left := ""
+		for _, name := range []string{"query.parallelProcess", "anonymous.procData", "anonymous.SaveData", "worker.(*Group).Go"} {
+			if strings.Contains(stacks, name) {
+				left = name
+			}
+		}
+		if left == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("routine %s is left:\n%s", left, stacks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+/* [Internal function] Check export status trailer and event of the export */
+func checkExportResult(t *testing.T, rec *httptest.ResponseRecorder, requestID string, status string, event string) {
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if trailer := rec.Header().Get("X-Export-Status"); trailer != status {
+		t.Fatalf("X-Export-Status = %q, want %q", trailer, status)
+	}
+	rows, err := strconv.Atoi(rec.Header().Get("X-Export-Rows"))
+	if err != nil || rows >= sourceRows {
+		t.Fatalf("X-Export-Rows = %q", rec.Header().Get("X-Export-Rows"))
+	}
+	if count, err := storage.CountEvent(requestID, event); err != nil || count != 1 {
+		t.Fatalf("%s events = %d (%v)", event, count, err)
+	}
+	if count, err := storage.CountEvent(requestID, "Success"); err != nil || count != 0 {
+		t.Fatalf("success events = %d (%v)", count, err)
+	}
+	checkStages(t)
+}
+
+func TestExportAborted(t *testing.T) {
+	source := createSource(t)
+
+	t.Run("disconnect", func(t *testing.T) {
+		requestID := "abort-disconnect"
+		saveExportDefinition(t, requestID, source, "PROFILES")
+		// 첫 번째 전송 이후 클라이언트 연결 종료
+		reqCtx, disconnect := context.WithCancel(context.Background())
+		defer disconnect()
+		rec := httptest.NewRecorder()
+		exportWith(t, reqCtx, requestID, &hookedWriter{ResponseRecorder: rec, hook: func() error {
+			disconnect()
+			return nil
+		}})
+		checkExportResult(t, rec, requestID, ES_ABORTED, "Aborted")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		requestID := "abort-timeout"
+		saveExportDefinition(t, requestID, source, "PROFILES")
+		config := &configs.Get().Export
+		timeout := config.Timeout
+		config.Timeout = 1
+		defer func() { config.Timeout = timeout }()
+		// 느린 클라이언트
+		rec := httptest.NewRecorder()
+		exportWith(t, context.Background(), requestID, &hookedWriter{ResponseRecorder: rec, hook: func() error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}})
+		checkExportResult(t, rec, requestID, ES_ABORTED, "Aborted")
+	})
+
+	t.Run("writer", func(t *testing.T) {
+		requestID := "abort-writer"
+		saveExportDefinition(t, requestID, source, "PROFILES")
+		// 전송 오류는 클라이언트 연결이 끊어진 것으로 처리
+		writes := 0
+		rec := httptest.NewRecorder()
+		exportWith(t, context.Background(), requestID, &hookedWriter{ResponseRecorder: rec, hook: func() error {
+			if writes++; writes > 3 {
+				return errors.New("broken pipe")
+			}
+			return nil
+		}})
+		checkExportResult(t, rec, requestID, ES_ABORTED, "Aborted")
+	})
+}
+
+func TestExportFailed(t *testing.T) {
+	// 마지막 분할 쿼리에서 변환할 수 없는 값
+	source := createSource(t, `UPDATE PROFILES SET AGE = 'invalid' WHERE PROFILES_ID = `+strconv.Itoa(sourceRows))
+
+	t.Run("table", func(t *testing.T) {
+		requestID := "fail-table"
+		saveExportDefinition(t, requestID, source, "MISSING")
+		// 전송 전에 실패한 경우 오류 응답 (trailer 없음)
+		rec := httptest.NewRecorder()
+		exportWith(t, context.Background(), requestID, rec)
+		if rec.Code != http.StatusInternalServerError || rec.Header().Get("X-Export-Status") != "" {
+			t.Fatalf("status = %d, X-Export-Status = %q", rec.Code, rec.Header().Get("X-Export-Status"))
+		}
+		if count, err := storage.CountEvent(requestID, "Failed"); err != nil || count != 1 {
+			t.Fatalf("failed events = %d (%v)", count, err)
+		}
+		checkStages(t)
+	})
+
+	t.Run("partition", func(t *testing.T) {
+		requestID := "fail-partition"
+		saveExportDefinition(t, requestID, source, "PROFILES")
+		rec := httptest.NewRecorder()
+		exportWith(t, context.Background(), requestID, rec)
+		checkExportResult(t, rec, requestID, ES_FAILED, "Failed")
+	})
+}
+
+/* [Internal function] Create SQLite source database (profiles and consents, statements are executed after the data is inserted) */
+func createSource(t *testing.T, statements ...string) string {
+	file := filepath.Join(t.TempDir(), "source.db")
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, statement := range []string{
+		`CREATE TABLE PROFILES (PROFILES_ID INTEGER, NAME TEXT, AGE INTEGER, MEMO TEXT, LAST_ACCESSED TEXT)`,
+		`CREATE TABLE CONSENTS (PROFILES_ID INTEGER, NAME INTEGER)`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := tx.Prepare(`INSERT INTO PROFILES VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consent, err := tx.Prepare(`INSERT INTO CONSENTS VALUES (?, ?)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessed := time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+	for id := 1; id <= sourceRows; id++ {
+		var age interface{} = id % 90
+		if id%7 == 0 {
+			age = nil
+		}
+		if _, err := profile.Exec(id, "name-"+strconv.Itoa(id), age, "memo", accessed); err != nil {
+			t.Fatal(err)
+		}
+		agreed := 1
+		if id%10 == 0 {
+			agreed = 0
+		}
+		if _, err := consent.Exec(id, agreed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return file
+}
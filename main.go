@@ -1,17 +1,115 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
 	// Echo
 	middleware "github.com/labstack/echo/middleware"
 	// Router
 	requestRouter "dems-api-server/routes"
+	requestHandler "dems-api-server/handlers/request"
+	"dems-api-server/configs"
+	"dems-api-server/controllers/apikey"
+	"dems-api-server/controllers/artifact"
+	"dems-api-server/controllers/audit"
+	"dems-api-server/controllers/job"
+	"dems-api-server/controllers/storage"
 )
 
 func main() {
+	// API key management (e.g. dems-api-server key issue <requestID>)
+	if len(os.Args) > 1 && os.Args[1] == "key" {
+		if err := apikey.Command(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	// Audit log verification (e.g. dems-api-server audit verify)
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		if err := audit.Command(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	// Database migration and import of files (e.g. dems-api-server db import)
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		if err := storage.Command(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	echo := requestRouter.Router()
 	// Set middleware
 	echo.Use(middleware.Logger())
 	echo.Use(middleware.Recover())
-	// Start
-	echo.Logger.Fatal(echo.Start(":4000"))
+	// Open database (schema is migrated before the server starts)
+	if _, err := storage.Open(); err != nil {
+		echo.Logger.Fatal(err)
+	}
+	// Import files of the previous version on the first start (requests table is empty)
+	imported, err := storage.ImportIfEmpty(storage.DEFAULT_DEFINITION_DIR, []string{storage.DEFAULT_ACCESS_LOG})
+	if err != nil {
+		echo.Logger.Fatal(errors.New("Failed to import files: " + err.Error()))
+	}
+	if imported != nil {
+		log.Print("Imported " + strconv.Itoa(imported.Requests) + " requests and " + strconv.Itoa(imported.Events) + " events")
+	}
+	// Remove expired exports in the artifact store
+	artifact.Start()
+	// Start background export job workers after the requests are imported (with the same handler as the synchronous export)
+	if err := job.Start(echo, requestHandler.ExportRequest); err != nil {
+		echo.Logger.Fatal(err)
+	}
+	// Start (HTTPS with client certificate verification if configured)
+	server := configs.Get().Server
+	if server.TLS.CertFile == "" {
+		echo.Logger.Fatal(echo.Start(server.Address))
+	}
+	tlsConfig, err := loadTLSConfig(server.TLS)
+	if err != nil {
+		echo.Logger.Fatal(err)
+	}
+	echo.TLSServer.Addr = server.Address
+	echo.TLSServer.TLSConfig = tlsConfig
+	echo.Logger.Fatal(echo.StartServer(echo.TLSServer))
+}
+
+/* [Internal function] TLS configuration of server (client certificates are verified by the client CA) */
+func loadTLSConfig(config configs.TLSConfig) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if config.ClientCAFile != "" {
+		content, err := ioutil.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errors.New("No certificate in " + config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if config.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	} else if config.RequireClientCert {
+		return nil, errors.New("clientCAFile is required to verify client certificates")
+	}
+	return tlsConfig, nil
 }
\ No newline at end of file
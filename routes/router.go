@@ -5,6 +5,7 @@ import (
 	// Echo
 	echo "github.com/labstack/echo"
 	requestHandler "dems-api-server/handlers/request"
+	"dems-api-server/controllers/oidc"
 )
 
 func Router() *echo.Echo {
@@ -19,11 +20,35 @@ func Router() *echo.Echo {
 	})
 
 	// Create router groups
-	requestRouter := e.Group("/request")
+	// Roles of each route (API key of the request is accepted as exporter of its own request), exports are rate limited
+	viewer := requestHandler.Authorize(oidc.ROLE_VIEWER)
+	exporter := requestHandler.Authorize(oidc.ROLE_EXPORTER)
+	admin := requestHandler.Authorize(oidc.ROLE_ADMIN)
+	// Networks and client certificates allowed by the request definition
+	restricted := requestHandler.RestrictAccess
+	// Requests of each client address are limited before authentication
+	limited := requestHandler.ClientLimit
+	requestRouter := e.Group("/request", limited)
 	{
-		requestRouter.GET("/list", requestHandler.RequestList)
-		requestRouter.GET("/:requestID", requestHandler.ExportRequest)
+		requestRouter.GET("/list", requestHandler.RequestList, viewer)
+		requestRouter.GET("/:requestID", requestHandler.ExportRequest, exporter, restricted, requestHandler.RateLimit)
+		requestRouter.GET("/:requestID/definition", requestHandler.GetDefinition, admin)
+		requestRouter.PUT("/:requestID/definition", requestHandler.UpdateDefinition, admin)
+		requestRouter.GET("/:requestID/exports/:exportID", requestHandler.DownloadExport, exporter, restricted)
+		requestRouter.HEAD("/:requestID/exports/:exportID", requestHandler.DownloadExport, exporter, restricted)
+		requestRouter.GET("/:requestID/exports/:exportID/manifest", requestHandler.ExportManifest, exporter, restricted)
+		requestRouter.POST("/:requestID/jobs", requestHandler.CreateJob, exporter, restricted, requestHandler.RateLimit)
 	}
+	// Background export jobs
+	jobRouter := e.Group("/jobs", limited, exporter)
+	{
+		jobRouter.GET("/:jobID", requestHandler.JobStatus)
+		jobRouter.GET("/:jobID/result", requestHandler.JobResult)
+	}
+	// Verify hash chain of the audit log
+	e.GET("/audit/verify", requestHandler.VerifyAudit, limited, admin)
+	// Public key to verify export manifests
+	e.GET("/manifest/key", requestHandler.ManifestPublicKey)
 
 	return e
 }
\ No newline at end of file